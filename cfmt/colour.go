@@ -22,23 +22,23 @@ const (
 	LightWhite   = "\x1b[97;1m"
 
 	// Background
-	BGBlack   = "\x1b[40;5m"
-	BGRed     = "\x1b[41;5m"
-	BGGreen   = "\x1b[42;5m"
-	BGYellow  = "\x1b[43;5m"
-	BGBlue    = "\x1b[44;5m"
-	BGMagenta = "\x1b[45;5m"
-	BGCyan    = "\x1b[46;5m"
-	BGWhite   = "\x1b[47;5m"
-
-	BGGrey         = "\x1b[100;5m"
-	BGLightRed     = "\x1b[101;5m"
-	BGLightGreen   = "\x1b[102;5m"
-	BGLightYellow  = "\x1b[103;5m"
-	BGLightBlue    = "\x1b[104;5m"
-	BGLightMagenta = "\x1b[105;5m"
-	BGLightCyan    = "\x1b[106;5m"
-	BGLightWhite   = "\x1b[107;5m"
+	BGBlack   = "\x1b[40m"
+	BGRed     = "\x1b[41m"
+	BGGreen   = "\x1b[42m"
+	BGYellow  = "\x1b[43m"
+	BGBlue    = "\x1b[44m"
+	BGMagenta = "\x1b[45m"
+	BGCyan    = "\x1b[46m"
+	BGWhite   = "\x1b[47m"
+
+	BGGrey         = "\x1b[100m"
+	BGLightRed     = "\x1b[101m"
+	BGLightGreen   = "\x1b[102m"
+	BGLightYellow  = "\x1b[103m"
+	BGLightBlue    = "\x1b[104m"
+	BGLightMagenta = "\x1b[105m"
+	BGLightCyan    = "\x1b[106m"
+	BGLightWhite   = "\x1b[107m"
 
 	// Other options
 	Bold          = "\x1b[1;1m"
@@ -51,3 +51,61 @@ const (
 	Concealed     = "\x1b[8;1m"
 	Strikethrough = "\x1b[9;1m"
 )
+
+// keywords maps markup keywords to their escape codes.
+var keywords = map[string]string{
+	"reset": Reset,
+
+	// Text colour
+	"black":    Black,
+	"red":      Red,
+	"green":    Green,
+	"yellow":   Yellow,
+	"blue":     Blue,
+	"magenta":  Magenta,
+	"cyan":     Cyan,
+	"white":    White,
+	"grey":     Grey,
+	"lred":     LightRed,
+	"lgreen":   LightGreen,
+	"lyellow":  LightYellow,
+	"lblue":    LightBlue,
+	"lmagenta": LightMagenta,
+	"lcyan":    LightCyan,
+	"lwhite":   LightWhite,
+
+	// Background colour
+	"bgblack":    BGBlack,
+	"bgred":      BGRed,
+	"bggreen":    BGGreen,
+	"bgyellow":   BGYellow,
+	"bgblue":     BGBlue,
+	"bgmagenta":  BGMagenta,
+	"bgcyan":     BGCyan,
+	"bgwhite":    BGWhite,
+	"bggrey":     BGGrey,
+	"bglred":     BGLightRed,
+	"bglgreen":   BGLightGreen,
+	"bglyellow":  BGLightYellow,
+	"bglblue":    BGLightBlue,
+	"bglmagenta": BGLightMagenta,
+	"bglcyan":    BGLightCyan,
+	"bglwhite":   BGLightWhite,
+
+	// Other styling
+	"bold":    Bold,
+	"fuzzy":   Fuzzy,
+	"italic":  Italic,
+	"under":   Underscore,
+	"blink":   Blink,
+	"fast":    FastBlink,
+	"reverse": Reverse,
+	"conceal": Concealed,
+	"strike":  Strikethrough,
+}
+
+// Code returns the escape code for a markup keyword, without the leading %.
+func Code(key string) (string, bool) {
+	code, ok := keywords[key]
+	return code, ok
+}
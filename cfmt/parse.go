@@ -6,131 +6,169 @@ import (
 	"os"
 	"strings"
 	"unicode"
-
-	"github.com/Urethramancer/signor/stringer"
 )
 
-// Print with colours, but no other formatting.
+// Print with colours, but no other formatting, to stdout. A newline is added.
 func Print(s string) {
-	colour(os.Stdout, s)
+	Fprint(os.Stdout, s)
 }
 
-// Printf with colours uses fmt.Printf().
+// Printf with colours uses fmt.Printf() after expanding the markup. A newline is added.
 func Printf(s string, v ...interface{}) {
-	buf := stringer.New()
-	colour(buf, s)
-	fmt.Printf(buf.String(), v...)
+	Fprintf(os.Stdout, s, v...)
+}
+
+// Fprint writes s with colours to w, adding a newline.
+// The markup is stripped if colour is disabled for w.
+func Fprint(w io.Writer, s string) (int, error) {
+	return io.WriteString(w, expand(s, Enabled(w), false)+"\n")
+}
+
+// Fprintf expands the markup in the format, then formats it with fmt.Fprintf() to w, adding a newline.
+// Arguments are not parsed for markup, so a % in user data is printed as is.
+func Fprintf(w io.Writer, s string, v ...interface{}) (int, error) {
+	return fmt.Fprintf(w, expand(s, Enabled(w), true)+"\n", v...)
+}
+
+// Sprint returns s with the markup expanded, or stripped if colour is disabled for stdout.
+// No newline is added.
+func Sprint(s string) string {
+	return expand(s, Enabled(os.Stdout), false)
+}
+
+// Sprintf expands the markup in the format like Sprint, then formats it with fmt.Sprintf().
+func Sprintf(s string, v ...interface{}) string {
+	return fmt.Sprintf(expand(s, Enabled(os.Stdout), true), v...)
+}
+
+// Strip removes all known markup from s.
+func Strip(s string) string {
+	return expand(s, false, false)
 }
 
-func colour(dst io.Writer, f string) {
+// expand replaces markup with escape codes, or removes it if colour is off.
+//
+// Single keywords like %red set a style until the next %reset. A single space
+// after them is skipped. Extended colours like %fg{208} and %bg{#ff8800} are
+// downsampled to Depth().
+//
+// Scopes like %{red,bold}text%{/} push styles on a stack, and closing a scope
+// restores the style from before it was opened, including single keywords.
+// Scopes take keywords without the percent sign, and fg:208 or bg:#ff8800 for
+// extended colours.
+//
+// %% is a literal percent sign. It is kept as %% for formats passed on to fmt.
+// Unknown keywords, including fmt verbs, are left alone. If anything was styled,
+// the output ends with a reset.
+func expand(f string, on, printf bool) string {
+	var b strings.Builder
+	// style holds the codes in effect since the last reset, and stack the
+	// style to restore when each open scope closes.
+	var style string
+	var stack []string
+	styled := false
+	emit := func(code string) {
+		if on {
+			b.WriteString(code)
+			styled = true
+		}
+	}
 	for len(f) > 0 {
-		c := f[0]
-		if c == '%' {
-			var key string
-			key, f = parseKeyword(f)
-			switch key {
-			case "reset":
-				dst.Write([]byte(Reset))
-
-			// Text colour
-			case "black":
-				dst.Write([]byte(Black))
-			case "red":
-				dst.Write([]byte(Red))
-			case "green":
-				dst.Write([]byte(Green))
-			case "yellow":
-				dst.Write([]byte(Yellow))
-			case "blue":
-				dst.Write([]byte(Blue))
-			case "magenta":
-				dst.Write([]byte(Magenta))
-			case "cyan":
-				dst.Write([]byte(Cyan))
-			case "white":
-				dst.Write([]byte(White))
-
-			case "grey":
-				dst.Write([]byte(Grey))
-			case "lred":
-				dst.Write([]byte(LightRed))
-			case "lgreen":
-				dst.Write([]byte(LightGreen))
-			case "lyellow":
-				dst.Write([]byte(LightYellow))
-			case "lblue":
-				dst.Write([]byte(LightBlue))
-			case "lmagenta":
-				dst.Write([]byte(LightMagenta))
-			case "lcyan":
-				dst.Write([]byte(LightCyan))
-			case "lwhite":
-				dst.Write([]byte(LightWhite))
-
-			// Background colour
-			case "bgblack":
-				dst.Write([]byte(BGBlack))
-			case "bgred":
-				dst.Write([]byte(BGRed))
-			case "bggreen":
-				dst.Write([]byte(BGGreen))
-			case "bgyellow":
-				dst.Write([]byte(BGYellow))
-			case "bgblue":
-				dst.Write([]byte(BGBlue))
-			case "bgmagenta":
-				dst.Write([]byte(BGMagenta))
-			case "bgcyan":
-				dst.Write([]byte(BGCyan))
-			case "bgwhite":
-				dst.Write([]byte(BGWhite))
-
-			case "bggrey":
-				dst.Write([]byte(BGGrey))
-			case "bglred":
-				dst.Write([]byte(BGLightRed))
-			case "bglgreen":
-				dst.Write([]byte(BGLightGreen))
-			case "bglyellow":
-				dst.Write([]byte(BGLightYellow))
-			case "bglblue":
-				dst.Write([]byte(BGLightBlue))
-			case "bglmagenta":
-				dst.Write([]byte(BGLightMagenta))
-			case "bglcyan":
-				dst.Write([]byte(BGLightCyan))
-			case "bglwhite":
-				dst.Write([]byte(BGLightWhite))
-
-			// Other styling
-			case "bold":
-				dst.Write([]byte(Bold))
-			case "fuzzy":
-				dst.Write([]byte(Fuzzy))
-			case "italic":
-				dst.Write([]byte(Italic))
-			case "under":
-				dst.Write([]byte(Underscore))
-			case "blink":
-				dst.Write([]byte(Blink))
-			case "fast":
-				dst.Write([]byte(FastBlink))
-			case "reverse":
-				dst.Write([]byte(Reverse))
-			case "conceal":
-				dst.Write([]byte(Concealed))
-			case "strike":
-				dst.Write([]byte(Strikethrough))
-			default:
-				dst.Write([]byte("%"))
-				dst.Write([]byte(key))
+		if f[0] != '%' {
+			b.WriteByte(f[0])
+			f = f[1:]
+			continue
+		}
+
+		if strings.HasPrefix(f, "%%") {
+			if printf {
+				b.WriteString("%%")
+			} else {
+				b.WriteByte('%')
+			}
+			f = f[2:]
+			continue
+		}
+
+		if strings.HasPrefix(f, "%{") {
+			end := strings.IndexByte(f, '}')
+			if end > 0 {
+				spec := f[2:end]
+				if spec == "/" {
+					if len(stack) > 0 {
+						style = stack[len(stack)-1]
+						stack = stack[:len(stack)-1]
+					}
+					emit(Reset + style)
+					f = f[end+1:]
+					continue
+				}
+
+				code, ok := scopeCode(spec)
+				if ok {
+					stack = append(stack, style)
+					style += code
+					emit(code)
+					f = f[end+1:]
+					continue
+				}
+			}
+		}
+
+		var key string
+		key, f = parseKeyword(f)
+		code, ok := keywords[key]
+		if !ok && isExtended(key) && len(f) > 0 && f[0] == '{' {
+			end := strings.IndexByte(f, '}')
+			if end > 0 {
+				code, ok = extendedCode(key, f[1:end])
+				if ok {
+					f = f[end+1:]
+				}
+			}
+		}
+		if ok {
+			if code == Reset {
+				style = ""
+			} else {
+				style += code
+			}
+			emit(code)
+			// A single space after a keyword only separates it from the text.
+			if len(f) > 1 && f[0] == ' ' {
+				f = f[1:]
 			}
 		} else {
-			dst.Write([]byte{f[0]})
-			f = f[1:]
+			b.WriteByte('%')
+			b.WriteString(key)
+		}
+	}
+	if styled {
+		b.WriteString(Reset)
+	}
+	return b.String()
+}
+
+// scopeCode returns the combined escape codes for a comma-separated scope spec.
+func scopeCode(spec string) (string, bool) {
+	var b strings.Builder
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		code, ok := keywords[name]
+		if !ok {
+			a := strings.SplitN(name, ":", 2)
+			if len(a) != 2 {
+				return "", false
+			}
+
+			code, ok = extendedCode(a[0], a[1])
+			if !ok {
+				return "", false
+			}
 		}
+		b.WriteString(code)
 	}
-	dst.Write([]byte("\n"))
+	return b.String(), true
 }
 
 // parseKeyword returns the parsed keyword and the rest of the input string.
@@ -142,17 +180,9 @@ func parseKeyword(f string) (string, string) {
 
 	b.WriteByte(f[0])
 	in := f[1:]
-	loop := true
-	for len(in) > 0 && loop {
-		if !unicode.IsLetter(rune(in[0])) {
-			loop = false
-			if len(in) > 1 && in[0] == ' ' {
-				in = in[1:]
-			}
-		} else {
-			b.WriteByte(in[0])
-			in = in[1:]
-		}
+	for len(in) > 0 && unicode.IsLetter(rune(in[0])) {
+		b.WriteByte(in[0])
+		in = in[1:]
 	}
 	return b.String()[1:], in
 }
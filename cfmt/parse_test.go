@@ -0,0 +1,70 @@
+package cfmt_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Urethramancer/signor/cfmt"
+)
+
+func TestFprintf(t *testing.T) {
+	var buf bytes.Buffer
+	cfmt.SetMode(cfmt.ModeAlways)
+	defer cfmt.SetMode(cfmt.ModeAuto)
+	cfmt.Fprintf(&buf, "%red Error:%reset %s is 100%% done", "%blue")
+	want := cfmt.Red + "Error:" + cfmt.Reset + "%blue is 100% done" + cfmt.Reset + "\n"
+	if buf.String() != want {
+		t.Errorf("Got %q, expected %q", buf.String(), want)
+	}
+}
+
+func TestStrip(t *testing.T) {
+	var buf bytes.Buffer
+	// Buffers aren't terminals, so the markup is stripped.
+	cfmt.Fprint(&buf, "%bold Title%reset: %s text")
+	if buf.String() != "Title: %s text\n" {
+		t.Errorf("Got %q", buf.String())
+	}
+
+	t.Setenv("FORCE_COLOR", "1")
+	if !cfmt.Enabled(&buf) {
+		t.Errorf("FORCE_COLOR should enable colour")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if cfmt.Enabled(&buf) {
+		t.Errorf("NO_COLOR should win over FORCE_COLOR")
+	}
+
+	if cfmt.Strip("%green ok") != "ok" {
+		t.Errorf("Strip() left markup: %q", cfmt.Strip("%green ok"))
+	}
+}
+
+func TestScopes(t *testing.T) {
+	cfmt.SetMode(cfmt.ModeAlways)
+	cfmt.SetDepth(cfmt.Depth16)
+	defer cfmt.SetMode(cfmt.ModeAuto)
+	defer cfmt.SetDepth(cfmt.DepthAuto)
+
+	got := cfmt.Sprint("%{red}a %{bold,bg:4}b%{/} c%{/} d 100%%")
+	want := cfmt.Red + "a " + cfmt.Bold + "\x1b[44m" + "b" + cfmt.Reset + cfmt.Red + " c" + cfmt.Reset + " d 100%" + cfmt.Reset
+	if got != want {
+		t.Errorf("Got %q, expected %q", got, want)
+	}
+
+	// Closing a scope restores a plain keyword from before it.
+	got = cfmt.Sprint("%red a %{bold}b%{/} c")
+	want = cfmt.Red + "a " + cfmt.Bold + "b" + cfmt.Reset + cfmt.Red + " c" + cfmt.Reset
+	if got != want {
+		t.Errorf("Got %q, expected %q", got, want)
+	}
+
+	if cfmt.Strip("%{green}ok%{/} 50%%") != "ok 50%" {
+		t.Errorf("Strip() left markup: %q", cfmt.Strip("%{green}ok%{/} 50%%"))
+	}
+
+	if cfmt.Sprint("plain %{nope}") != "plain %{nope}" {
+		t.Errorf("Unknown scopes should be left alone: %q", cfmt.Sprint("plain %{nope}"))
+	}
+}
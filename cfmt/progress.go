@@ -0,0 +1,399 @@
+package cfmt
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Progress is a progress bar or spinner. On a terminal it redraws in place below
+// other output, and otherwise it writes a status line every LogInterval.
+// All methods are safe for concurrent use.
+type Progress struct {
+	sync.Mutex
+	w       io.Writer
+	tty     bool
+	colour  bool
+	label   string
+	total   int64
+	current int64
+	spinner bool
+	frame   int
+	start   time.Time
+	logged  time.Time
+	drawn   time.Time
+	pending bool
+	done    bool
+	stop    chan struct{}
+}
+
+const (
+	// barWidth is the number of cells in the bar itself.
+	barWidth = 30
+	// tickInterval is how often spinners and indeterminate bars animate.
+	tickInterval = 100 * time.Millisecond
+	// redrawInterval is the shortest time between redraws from count changes.
+	redrawInterval = 50 * time.Millisecond
+)
+
+// LogInterval is how often progress is written as plain lines when the output
+// isn't a terminal.
+var LogInterval = 5 * time.Second
+
+// spinnerFrames for spinners on terminals.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// NewBar starts a progress bar on w. A total of 0 or less makes it indeterminate,
+// showing only a count.
+func NewBar(w io.Writer, label string, total int64) *Progress {
+	p := &Progress{
+		w:      w,
+		tty:    IsTerminal(w),
+		colour: Enabled(w),
+		label:  label,
+		total:  total,
+	}
+	p.begin()
+	return p
+}
+
+// NewSpinner starts a spinner on w.
+func NewSpinner(w io.Writer, label string) *Progress {
+	p := &Progress{
+		w:       w,
+		tty:     IsTerminal(w),
+		colour:  Enabled(w),
+		label:   label,
+		spinner: true,
+	}
+	p.begin()
+	return p
+}
+
+// begin registers the progress and starts the animation or the first log line.
+func (p *Progress) begin() {
+	p.start = time.Now()
+	p.logged = p.start
+	if !p.tty {
+		fmt.Fprintf(p.w, "%s: started\n", p.label)
+		return
+	}
+
+	screen.add(p)
+	if p.spinner || p.total <= 0 {
+		p.stop = make(chan struct{})
+		go p.animate()
+	}
+}
+
+// animate redraws until the progress is finished.
+func (p *Progress) animate() {
+	t := time.NewTicker(tickInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-t.C:
+			p.Lock()
+			p.frame++
+			p.Unlock()
+			screen.redraw(p.w)
+		}
+	}
+}
+
+// Add n to the current count.
+func (p *Progress) Add(n int64) {
+	p.Lock()
+	p.current += n
+	p.Unlock()
+	p.update()
+}
+
+// Set the current count.
+func (p *Progress) Set(n int64) {
+	p.Lock()
+	p.current = n
+	p.Unlock()
+	p.update()
+}
+
+// SetLabel changes the text in front of the bar.
+func (p *Progress) SetLabel(label string) {
+	p.Lock()
+	p.label = label
+	p.Unlock()
+	p.update()
+}
+
+// update redraws on terminals, or logs if the interval has passed.
+// Terminal redraws are throttled, with one more scheduled for changes in between.
+func (p *Progress) update() {
+	if p.tty {
+		p.Lock()
+		if p.done || p.pending {
+			p.Unlock()
+			return
+		}
+
+		wait := redrawInterval - time.Since(p.drawn)
+		if wait > 0 {
+			p.pending = true
+			p.Unlock()
+			time.AfterFunc(wait, p.redraw)
+			return
+		}
+
+		p.drawn = time.Now()
+		p.Unlock()
+		screen.redraw(p.w)
+		return
+	}
+
+	p.Lock()
+	if p.done || time.Since(p.logged) < LogInterval {
+		p.Unlock()
+		return
+	}
+
+	p.logged = time.Now()
+	s := p.status()
+	p.Unlock()
+	fmt.Fprintln(p.w, s)
+}
+
+// redraw after a throttled update.
+func (p *Progress) redraw() {
+	p.Lock()
+	p.pending = false
+	p.drawn = time.Now()
+	p.Unlock()
+	screen.redraw(p.w)
+}
+
+// Finish stops the progress, leaving its final state on screen.
+func (p *Progress) Finish() {
+	p.Lock()
+	if p.done {
+		p.Unlock()
+		return
+	}
+
+	p.done = true
+	if p.total > 0 && p.current < p.total && !p.spinner {
+		p.current = p.total
+	}
+	if p.stop != nil {
+		close(p.stop)
+	}
+	p.Unlock()
+
+	if p.tty {
+		screen.remove(p)
+		return
+	}
+
+	p.Lock()
+	s := p.status()
+	p.Unlock()
+	fmt.Fprintln(p.w, s)
+}
+
+// line renders the terminal line. The caller holds the lock.
+func (p *Progress) line() string {
+	elapsed := time.Since(p.start).Round(time.Second)
+	if p.spinner {
+		if p.done {
+			return fmt.Sprintf("%s %s (%s)", p.paint(Green, "✓"), p.label, elapsed)
+		}
+		return fmt.Sprintf("%s %s (%s)", p.paint(Cyan, spinnerFrames[p.frame%len(spinnerFrames)]), p.label, elapsed)
+	}
+
+	var bar string
+	if p.total > 0 {
+		filled := int(p.current * barWidth / p.total)
+		if filled > barWidth {
+			filled = barWidth
+		}
+		bar = strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+		return fmt.Sprintf("%s [%s] %3d%% (%d/%d)", p.label, bar, p.current*100/p.total, p.current, p.total)
+	}
+
+	if p.done {
+		bar = strings.Repeat("█", barWidth)
+	} else {
+		// Bounce a block back and forth.
+		pos := p.frame % (2 * (barWidth - 3))
+		if pos >= barWidth-3 {
+			pos = 2*(barWidth-3) - pos
+		}
+		bar = strings.Repeat("░", pos) + "███" + strings.Repeat("░", barWidth-3-pos)
+	}
+	return fmt.Sprintf("%s [%s] %d", p.label, bar, p.current)
+}
+
+// paint s in a colour if enabled.
+func (p *Progress) paint(code, s string) string {
+	if !p.colour {
+		return s
+	}
+
+	return code + s + Reset
+}
+
+// status renders a plain log line. The caller holds the lock.
+func (p *Progress) status() string {
+	elapsed := time.Since(p.start).Round(time.Millisecond)
+	state := "working"
+	if p.done {
+		state = "done"
+	}
+	switch {
+	case p.spinner:
+		return fmt.Sprintf("%s: %s (%s)", p.label, state, elapsed)
+	case p.total > 0:
+		return fmt.Sprintf("%s: %s %d%% (%d/%d, %s)", p.label, state, p.current*100/p.total, p.current, p.total, elapsed)
+	}
+
+	return fmt.Sprintf("%s: %s (%d, %s)", p.label, state, p.current, elapsed)
+}
+
+// display is the block of progress lines at the bottom of one terminal writer.
+type display struct {
+	list  []*Progress
+	lines int
+}
+
+// terminalScreen tracks displays for all terminal writers.
+type terminalScreen struct {
+	sync.Mutex
+	displays map[io.Writer]*display
+	// active is the number of displays, readable without the lock.
+	active atomic.Int32
+}
+
+var screen = &terminalScreen{displays: make(map[io.Writer]*display)}
+
+func (s *terminalScreen) add(p *Progress) {
+	s.Lock()
+	defer s.Unlock()
+	d, ok := s.displays[p.w]
+	if !ok {
+		d = &display{}
+		s.displays[p.w] = d
+		s.active.Add(1)
+	}
+	s.clear(p.w, d)
+	d.list = append(d.list, p)
+	s.draw(p.w, d)
+}
+
+// remove draws the final state of p above the remaining progress lines.
+func (s *terminalScreen) remove(p *Progress) {
+	s.Lock()
+	defer s.Unlock()
+	d, ok := s.displays[p.w]
+	if !ok {
+		return
+	}
+
+	s.clear(p.w, d)
+	for i, x := range d.list {
+		if x == p {
+			d.list = append(d.list[:i], d.list[i+1:]...)
+			break
+		}
+	}
+	p.Lock()
+	io.WriteString(p.w, p.line()+"\n")
+	p.Unlock()
+	if len(d.list) == 0 {
+		delete(s.displays, p.w)
+		s.active.Add(-1)
+		return
+	}
+
+	s.draw(p.w, d)
+}
+
+func (s *terminalScreen) redraw(w io.Writer) {
+	s.Lock()
+	defer s.Unlock()
+	d, ok := s.displays[w]
+	if !ok {
+		return
+	}
+
+	s.clear(w, d)
+	s.draw(w, d)
+}
+
+// clear erases the display, leaving the cursor at the start of its first line.
+func (s *terminalScreen) clear(w io.Writer, d *display) {
+	if d.lines == 0 {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("\r\x1b[K")
+	for i := 1; i < d.lines; i++ {
+		b.WriteString("\x1b[1A\x1b[K")
+	}
+	io.WriteString(w, b.String())
+	d.lines = 0
+}
+
+// draw writes all lines of the display, leaving the cursor at the end of the last.
+func (s *terminalScreen) draw(w io.Writer, d *display) {
+	width := TerminalWidth(w)
+	lines := make([]string, len(d.list))
+	for i, p := range d.list {
+		p.Lock()
+		lines[i] = p.line()
+		p.Unlock()
+		if width > 0 {
+			lines[i] = Truncate(lines[i], width-1, "…")
+		}
+	}
+	io.WriteString(w, strings.Join(lines, "\n"))
+	d.lines = len(lines)
+}
+
+// InterruptFor runs f, which writes to w, through Interrupt() if w is a terminal
+// while progress is shown, and otherwise only calls f. Writes to files and
+// buffers leave the displays alone. Any terminal interrupts all displays, since
+// stdout and stderr usually share one screen.
+func InterruptFor(w io.Writer, f func()) {
+	if screen.active.Load() == 0 || !IsTerminal(w) {
+		f()
+		return
+	}
+
+	Interrupt(f)
+}
+
+// Interrupt runs f, which writes to the terminal, with any progress displays
+// temporarily cleared so the output appears above them. Loggers writing to a
+// terminal should wrap their writes with it, or with InterruptFor() if they may
+// write elsewhere. Without any progress displays it only calls f.
+func Interrupt(f func()) {
+	if screen.active.Load() == 0 {
+		f()
+		return
+	}
+
+	screen.Lock()
+	defer screen.Unlock()
+
+	for w, d := range screen.displays {
+		screen.clear(w, d)
+	}
+	f()
+	for w, d := range screen.displays {
+		screen.draw(w, d)
+	}
+}
@@ -0,0 +1,137 @@
+package cfmt_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/Urethramancer/signor/cfmt"
+)
+
+// openPTY returns both ends of a new pseudo-terminal, with everything written to
+// the terminal end collected from the other.
+func openPTY(t *testing.T) (*os.File, *bytes.Buffer, func()) {
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("Couldn't open a pseudo-terminal: %s", err.Error())
+	}
+
+	var unlock int32
+	var n uint32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, ptmx.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock)))
+	if errno == 0 {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, ptmx.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n)))
+	}
+	if errno != 0 {
+		ptmx.Close()
+		t.Skipf("Couldn't set up the pseudo-terminal: %s", errno.Error())
+	}
+
+	tty, err := os.OpenFile("/dev/pts/"+strconv.Itoa(int(n)), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		ptmx.Close()
+		t.Skipf("Couldn't open the pseudo-terminal: %s", err.Error())
+	}
+
+	var out bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&out, ptmx)
+		close(done)
+	}()
+	return tty, &out, func() {
+		// Reads return EIO once the terminal end is closed and drained.
+		tty.Close()
+		<-done
+		ptmx.Close()
+	}
+}
+
+func TestProgressTerminal(t *testing.T) {
+	tty, out, closeTTY := openPTY(t)
+	if !cfmt.IsTerminal(tty) {
+		closeTTY()
+		t.Skip("The pseudo-terminal isn't a character device")
+	}
+
+	bar := cfmt.NewBar(tty, "copy", 1000)
+	for i := 0; i < 1000; i++ {
+		bar.Add(1)
+	}
+	cfmt.InterruptFor(tty, func() {
+		io.WriteString(tty, "log line\r\n")
+	})
+
+	// Writes elsewhere don't touch the display, so Interrupt() can't deadlock here.
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go cfmt.InterruptFor(&buf, func() {
+		cfmt.Interrupt(func() {
+			buf.WriteString("file line\n")
+		})
+		close(done)
+	})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("InterruptFor() cleared the display for a buffer")
+	}
+
+	if buf.String() != "file line\n" {
+		t.Errorf("Unexpected buffer output %q", buf.String())
+	}
+	time.Sleep(100 * time.Millisecond)
+	bar.Finish()
+	cfmt.InterruptFor(tty, func() {
+		io.WriteString(tty, "after\r\n")
+	})
+	closeTTY()
+
+	s := out.String()
+	if !strings.Contains(s, "log line") || !strings.Contains(s, "after") {
+		t.Fatalf("Output missing:\n%q", s)
+	}
+
+	// Throttling keeps redraws far below one per Add().
+	draws := strings.Count(s, "copy [")
+	if draws < 2 || draws > 100 {
+		t.Errorf("Expected a few redraws, got %d:\n%q", draws, s)
+	}
+
+	// The log line clears the bar before it, and the bar is drawn again below it.
+	i := strings.Index(s, "log line")
+	if !strings.HasSuffix(s[:i], "\x1b[K") || !strings.Contains(s[i:], "copy [") {
+		t.Errorf("Log line wasn't written above the bar:\n%q", s)
+	}
+
+	// The final state stays above later output.
+	last := strings.LastIndex(s, "copy [")
+	if !strings.Contains(s[last:], "100% (1000/1000)") || last > strings.Index(s, "after") {
+		t.Errorf("Final state not left on screen:\n%q", s)
+	}
+}
+
+func TestTerminalWidth(t *testing.T) {
+	t.Setenv("COLUMNS", "42")
+	var buf bytes.Buffer
+	if cfmt.TerminalWidth(&buf) != 0 {
+		t.Errorf("COLUMNS used for a writer which isn't a terminal")
+	}
+
+	// New pseudo-terminals have no size, so COLUMNS is the fallback.
+	tty, _, closeTTY := openPTY(t)
+	defer closeTTY()
+	if !cfmt.IsTerminal(tty) {
+		t.Skip("The pseudo-terminal isn't a character device")
+	}
+
+	if cfmt.TerminalWidth(tty) != 42 {
+		t.Errorf("Expected width 42 from COLUMNS, got %d", cfmt.TerminalWidth(tty))
+	}
+}
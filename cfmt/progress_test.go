@@ -0,0 +1,52 @@
+package cfmt_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Urethramancer/signor/cfmt"
+)
+
+// lockedBuffer is a bytes.Buffer safe for concurrent writes.
+type lockedBuffer struct {
+	sync.Mutex
+	bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.Buffer.Write(p)
+}
+
+func TestProgress(t *testing.T) {
+	var buf lockedBuffer
+	bar := cfmt.NewBar(&buf, "copy", 100)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				bar.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	bar.Finish()
+	bar.Finish()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || lines[0] != "copy: started" || !strings.HasPrefix(lines[1], "copy: done 100% (100/100") {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	sp := cfmt.NewSpinner(&buf, "wait")
+	sp.Finish()
+	if !strings.Contains(buf.String(), "wait: done") {
+		t.Errorf("Unexpected spinner output:\n%s", buf.String())
+	}
+}
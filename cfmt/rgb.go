@@ -0,0 +1,260 @@
+package cfmt
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Colour depths for SetDepth().
+const (
+	// DepthAuto picks the depth from COLORTERM and TERM.
+	DepthAuto = 0
+	// Depth16 is the basic ANSI palette.
+	Depth16 = 16
+	// Depth256 is the xterm 256-colour palette.
+	Depth256 = 256
+	// DepthTrue is 24-bit colour.
+	DepthTrue = 1 << 24
+)
+
+var (
+	depthMu sync.RWMutex
+	depth   = DepthAuto
+)
+
+// SetDepth overrides the detected colour depth.
+func SetDepth(d int) {
+	depthMu.Lock()
+	defer depthMu.Unlock()
+	depth = d
+}
+
+// Depth returns the colour depth extended colours are downsampled to.
+// COLORTERM=truecolor or 24bit gives DepthTrue, a TERM containing "256color"
+// gives Depth256, and anything else Depth16.
+func Depth() int {
+	depthMu.RLock()
+	d := depth
+	depthMu.RUnlock()
+	if d != DepthAuto {
+		return d
+	}
+
+	ct := strings.ToLower(os.Getenv("COLORTERM"))
+	if ct == "truecolor" || ct == "24bit" {
+		return DepthTrue
+	}
+
+	if strings.Contains(os.Getenv("TERM"), "256color") {
+		return Depth256
+	}
+
+	return Depth16
+}
+
+// RGB is a 24-bit colour.
+type RGB struct {
+	R, G, B uint8
+}
+
+// ParseColour parses a palette index ("208"), hex ("#ff8800" or "#f80")
+// or comma-separated RGB ("255,136,0") colour.
+// Palette indices are returned with index set and the RGB value from the palette.
+func ParseColour(s string) (c RGB, index int, ok bool) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, "#"):
+		h := s[1:]
+		if len(h) == 3 {
+			h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+		}
+		if len(h) != 6 {
+			return c, -1, false
+		}
+
+		v, err := strconv.ParseUint(h, 16, 32)
+		if err != nil {
+			return c, -1, false
+		}
+
+		return RGB{uint8(v >> 16), uint8(v >> 8), uint8(v)}, -1, true
+	case strings.Contains(s, ","):
+		a := strings.Split(s, ",")
+		if len(a) != 3 {
+			return c, -1, false
+		}
+
+		var v [3]uint8
+		for i := range a {
+			n, err := strconv.ParseUint(strings.TrimSpace(a[i]), 10, 8)
+			if err != nil {
+				return c, -1, false
+			}
+			v[i] = uint8(n)
+		}
+		return RGB{v[0], v[1], v[2]}, -1, true
+	}
+
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return c, -1, false
+	}
+
+	return paletteRGB(int(n)), int(n), true
+}
+
+// FG returns the escape code for a foreground colour at the current depth.
+func FG(c RGB) string {
+	return rgbCode(c, -1, false, Depth())
+}
+
+// BG returns the escape code for a background colour at the current depth.
+func BG(c RGB) string {
+	return rgbCode(c, -1, true, Depth())
+}
+
+// extendedCode returns the escape code for the fg, bg, rgb and bgrgb keywords.
+func extendedCode(key, arg string) (string, bool) {
+	c, index, ok := ParseColour(arg)
+	if !ok {
+		return "", false
+	}
+
+	switch key {
+	case "fg":
+		return rgbCode(c, index, false, Depth()), true
+	case "bg":
+		return rgbCode(c, index, true, Depth()), true
+	case "rgb":
+		return rgbCode(c, -1, false, Depth()), true
+	case "bgrgb":
+		return rgbCode(c, -1, true, Depth()), true
+	}
+
+	return "", false
+}
+
+// isExtended returns true for keywords taking a {colour} argument.
+func isExtended(key string) bool {
+	switch key {
+	case "fg", "bg", "rgb", "bgrgb":
+		return true
+	}
+
+	return false
+}
+
+// rgbCode builds an escape code, downsampling to the depth.
+// Index is the palette index if the colour was given as one, or -1.
+func rgbCode(c RGB, index int, bg bool, d int) string {
+	base := 38
+	if bg {
+		base = 48
+	}
+
+	switch {
+	case d >= DepthTrue && index < 0:
+		return "\x1b[" + strconv.Itoa(base) + ";2;" + strconv.Itoa(int(c.R)) + ";" +
+			strconv.Itoa(int(c.G)) + ";" + strconv.Itoa(int(c.B)) + "m"
+	case d >= Depth256:
+		if index < 0 {
+			index = nearest256(c)
+		}
+		return "\x1b[" + strconv.Itoa(base) + ";5;" + strconv.Itoa(index) + "m"
+	}
+
+	if index < 0 || index > 15 {
+		index = nearest16(c)
+	}
+	code := 30 + index
+	if index > 7 {
+		code = 90 + index - 8
+	}
+	if bg {
+		code += 10
+	}
+	return "\x1b[" + strconv.Itoa(code) + "m"
+}
+
+// ansi16 is the typical xterm rendition of the basic palette.
+var ansi16 = [16]RGB{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubeLevels are the channel values of the 6x6x6 colour cube.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// paletteRGB returns the RGB value of a 256-colour palette index.
+func paletteRGB(i int) RGB {
+	switch {
+	case i < 16:
+		return ansi16[i]
+	case i < 232:
+		i -= 16
+		return RGB{cubeLevels[i/36], cubeLevels[(i/6)%6], cubeLevels[i%6]}
+	}
+
+	v := uint8(8 + (i-232)*10)
+	return RGB{v, v, v}
+}
+
+// nearest256 finds the closest colour in the cube or greyscale ramp.
+func nearest256(c RGB) int {
+	ci := 16 + 36*cubeIndex(c.R) + 6*cubeIndex(c.G) + cubeIndex(c.B)
+	avg := (int(c.R) + int(c.G) + int(c.B)) / 3
+	gi := 232
+	if avg > 238 {
+		gi = 255
+	} else if avg > 8 {
+		gi = 232 + (avg-8)/10
+	}
+
+	if distance(c, paletteRGB(gi)) < distance(c, paletteRGB(ci)) {
+		return gi
+	}
+
+	return ci
+}
+
+// cubeIndex returns the nearest cube level for a channel.
+func cubeIndex(v uint8) int {
+	best := 0
+	for i, l := range cubeLevels {
+		if abs(int(v)-int(l)) < abs(int(v)-int(cubeLevels[best])) {
+			best = i
+		}
+	}
+	return best
+}
+
+// nearest16 finds the closest basic colour.
+func nearest16(c RGB) int {
+	best := 0
+	for i := range ansi16 {
+		if distance(c, ansi16[i]) < distance(c, ansi16[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// distance is the squared euclidean distance between two colours.
+func distance(a, b RGB) int {
+	r := int(a.R) - int(b.R)
+	g := int(a.G) - int(b.G)
+	bl := int(a.B) - int(b.B)
+	return r*r + g*g + bl*bl
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+
+	return i
+}
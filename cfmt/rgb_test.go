@@ -0,0 +1,49 @@
+package cfmt_test
+
+import (
+	"testing"
+
+	"github.com/Urethramancer/signor/cfmt"
+)
+
+func TestExtended(t *testing.T) {
+	cfmt.SetMode(cfmt.ModeAlways)
+	defer cfmt.SetMode(cfmt.ModeAuto)
+	defer cfmt.SetDepth(cfmt.DepthAuto)
+
+	tests := []struct {
+		depth int
+		in    string
+		want  string
+	}{
+		{cfmt.DepthTrue, "%fg{#ff8800}x", "\x1b[38;2;255;136;0mx\x1b[0m"},
+		{cfmt.DepthTrue, "%bg{208}x", "\x1b[48;5;208mx\x1b[0m"},
+		{cfmt.DepthTrue, "%rgb{1,2,3}x", "\x1b[38;2;1;2;3mx\x1b[0m"},
+		{cfmt.Depth256, "%fg{#ff8700}x", "\x1b[38;5;208mx\x1b[0m"},
+		{cfmt.Depth256, "%bgrgb{128,128,128}x", "\x1b[48;5;244mx\x1b[0m"},
+		{cfmt.Depth16, "%fg{#ff0000}x", "\x1b[91mx\x1b[0m"},
+		{cfmt.Depth16, "%bg{4}x", "\x1b[44mx\x1b[0m"},
+		{cfmt.Depth16, "%fg{nope}x", "%fg{nope}x"},
+		{cfmt.Depth16, "%red x", cfmt.Red + "x" + cfmt.Reset},
+	}
+
+	for _, tc := range tests {
+		cfmt.SetDepth(tc.depth)
+		got := cfmt.Sprint(tc.in)
+		if got != tc.want {
+			t.Errorf("%d %q: got %q, expected %q", tc.depth, tc.in, got, tc.want)
+		}
+	}
+
+	cfmt.SetDepth(cfmt.DepthAuto)
+	t.Setenv("COLORTERM", "truecolor")
+	if cfmt.Depth() != cfmt.DepthTrue {
+		t.Errorf("COLORTERM=truecolor should give DepthTrue")
+	}
+
+	t.Setenv("COLORTERM", "")
+	t.Setenv("TERM", "xterm-256color")
+	if cfmt.Depth() != cfmt.Depth256 {
+		t.Errorf("TERM=xterm-256color should give Depth256")
+	}
+}
@@ -0,0 +1,273 @@
+package cfmt
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Column alignment for tables.
+const (
+	AlignLeft = iota
+	AlignRight
+	AlignCenter
+)
+
+// BoxStyle holds the characters used to draw table borders.
+type BoxStyle struct {
+	Horizontal, Vertical               string
+	TopLeft, TopMid, TopRight          string
+	MidLeft, Cross, MidRight           string
+	BottomLeft, BottomMid, BottomRight string
+}
+
+// Table border styles.
+var (
+	// StylePlain has no borders, and separates columns with two spaces.
+	StylePlain = BoxStyle{}
+	// StyleASCII draws borders with plain ASCII characters.
+	StyleASCII = BoxStyle{"-", "|", "+", "+", "+", "+", "+", "+", "+", "+", "+"}
+	// StyleSingle draws single-line box borders.
+	StyleSingle = BoxStyle{"─", "│", "┌", "┬", "┐", "├", "┼", "┤", "└", "┴", "┘"}
+	// StyleDouble draws double-line box borders.
+	StyleDouble = BoxStyle{"═", "║", "╔", "╦", "╗", "╠", "╬", "╣", "╚", "╩", "╝"}
+	// StyleRounded draws single-line box borders with rounded corners.
+	StyleRounded = BoxStyle{"─", "│", "╭", "┬", "╮", "├", "┼", "┤", "╰", "┴", "╯"}
+)
+
+// Table renders rows of cells in aligned columns. Cells may contain colour markup,
+// which doesn't count towards the column widths.
+type Table struct {
+	header      []string
+	rows        [][]string
+	align       []int
+	style       BoxStyle
+	headerStyle string
+	maxWidth    int
+}
+
+// NewTable creates a table with the given column headers, which may be empty.
+// The default style is StyleSingle with bold headers.
+func NewTable(header ...string) *Table {
+	return &Table{
+		header:      header,
+		style:       StyleSingle,
+		headerStyle: "%bold",
+	}
+}
+
+// AddRow adds a row of cells, formatted with fmt.Sprint().
+func (t *Table) AddRow(cells ...interface{}) *Table {
+	row := make([]string, len(cells))
+	for i, c := range cells {
+		row[i] = strings.ReplaceAll(fmt.Sprint(c), "\n", " ")
+	}
+	t.rows = append(t.rows, row)
+	return t
+}
+
+// SetAlign sets the alignment of a column, counting from 0.
+func (t *Table) SetAlign(col, align int) *Table {
+	for len(t.align) <= col {
+		t.align = append(t.align, AlignLeft)
+	}
+	t.align[col] = align
+	return t
+}
+
+// SetStyle sets the border style.
+func (t *Table) SetStyle(s BoxStyle) *Table {
+	t.style = s
+	return t
+}
+
+// SetHeaderStyle sets the markup put in front of each header cell, e.g. "%bold%cyan".
+func (t *Table) SetHeaderStyle(markup string) *Table {
+	t.headerStyle = markup
+	return t
+}
+
+// SetMaxWidth limits the table width, truncating the widest columns to fit.
+// Zero uses the terminal width when printing, and negative values mean no limit.
+func (t *Table) SetMaxWidth(w int) *Table {
+	t.maxWidth = w
+	return t
+}
+
+// Print the table to stdout.
+func (t *Table) Print() {
+	t.Fprint(os.Stdout)
+}
+
+// Fprint renders the table to w. Writers which aren't terminals get the plain
+// style, and colour follows Enabled().
+func (t *Table) Fprint(w io.Writer) (int, error) {
+	style := t.style
+	if !IsTerminal(w) {
+		style = StylePlain
+	}
+
+	max := t.maxWidth
+	if max == 0 {
+		max = TerminalWidth(w)
+	}
+	return io.WriteString(w, t.render(Enabled(w), style, max))
+}
+
+// String renders the table in its style without colour or width limit.
+func (t *Table) String() string {
+	return t.render(false, t.style, t.maxWidth)
+}
+
+// render the table. A max of 0 or less means no width limit.
+func (t *Table) render(on bool, style BoxStyle, max int) string {
+	cols := len(t.header)
+	for _, r := range t.rows {
+		if len(r) > cols {
+			cols = len(r)
+		}
+	}
+	if cols == 0 {
+		return ""
+	}
+
+	// Expand markup and measure.
+	var header []string
+	if len(t.header) > 0 {
+		header = make([]string, cols)
+		for i, h := range t.header {
+			if on && t.headerStyle != "" {
+				header[i] = expand(t.headerStyle+h, true, false)
+			} else {
+				header[i] = expand(h, false, false)
+			}
+		}
+	}
+	rows := make([][]string, len(t.rows))
+	for i, r := range t.rows {
+		rows[i] = make([]string, cols)
+		for j, c := range r {
+			rows[i][j] = expand(c, on, false)
+		}
+	}
+
+	widths := make([]int, cols)
+	measure := func(r []string) {
+		for i, c := range r {
+			w := Width(c)
+			if w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	if header != nil {
+		measure(header)
+	}
+	for _, r := range rows {
+		measure(r)
+	}
+
+	bordered := style.Vertical != ""
+	overhead := (cols - 1) * 2
+	if bordered {
+		overhead = (cols+1)*Width(style.Vertical) + cols*2
+	}
+	if max > 0 {
+		shrink(widths, max-overhead)
+	}
+
+	var b strings.Builder
+	if bordered {
+		t.rule(&b, widths, style.TopLeft, style.TopMid, style.TopRight, style.Horizontal)
+	}
+	if header != nil {
+		t.row(&b, header, widths, style, bordered)
+		if bordered {
+			t.rule(&b, widths, style.MidLeft, style.Cross, style.MidRight, style.Horizontal)
+		}
+	}
+	for _, r := range rows {
+		t.row(&b, r, widths, style, bordered)
+	}
+	if bordered {
+		t.rule(&b, widths, style.BottomLeft, style.BottomMid, style.BottomRight, style.Horizontal)
+	}
+	return b.String()
+}
+
+// shrink reduces the widest columns one at a time until they fit in total.
+func shrink(widths []int, total int) {
+	sum := 0
+	for _, w := range widths {
+		sum += w
+	}
+
+	for sum > total {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= 1 {
+			return
+		}
+
+		widths[widest]--
+		sum--
+	}
+}
+
+// rule draws a horizontal border line.
+func (t *Table) rule(b *strings.Builder, widths []int, left, mid, right, h string) {
+	b.WriteString(left)
+	for i, w := range widths {
+		if i > 0 {
+			b.WriteString(mid)
+		}
+		b.WriteString(strings.Repeat(h, w+2))
+	}
+	b.WriteString(right)
+	b.WriteByte('\n')
+}
+
+// row draws one line of cells.
+func (t *Table) row(b *strings.Builder, cells []string, widths []int, style BoxStyle, bordered bool) {
+	var line strings.Builder
+	if bordered {
+		line.WriteString(style.Vertical)
+	}
+	for i, c := range cells {
+		c = Truncate(c, widths[i], "…")
+		align := AlignLeft
+		if i < len(t.align) {
+			align = t.align[i]
+		}
+
+		if bordered {
+			line.WriteByte(' ')
+		} else if i > 0 {
+			line.WriteString("  ")
+		}
+		switch align {
+		case AlignRight:
+			line.WriteString(PadLeft(c, widths[i]))
+		case AlignCenter:
+			line.WriteString(Center(c, widths[i]))
+		default:
+			line.WriteString(PadRight(c, widths[i]))
+		}
+		if bordered {
+			line.WriteByte(' ')
+			line.WriteString(style.Vertical)
+		}
+	}
+
+	s := line.String()
+	if !bordered {
+		s = strings.TrimRight(s, " ")
+	}
+	b.WriteString(s)
+	b.WriteByte('\n')
+}
@@ -0,0 +1,56 @@
+package cfmt_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Urethramancer/signor/cfmt"
+)
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"abc", 3},
+		{cfmt.Red + "abc" + cfmt.Reset, 3},
+		{"日本語", 6},
+		{"é", 1},
+	}
+	for _, tc := range tests {
+		if cfmt.Width(tc.in) != tc.want {
+			t.Errorf("Width(%q) = %d, expected %d", tc.in, cfmt.Width(tc.in), tc.want)
+		}
+	}
+
+	got := cfmt.Truncate(cfmt.Red+"abcdef", 4, "…")
+	if got != cfmt.Red+"abc…"+cfmt.Reset {
+		t.Errorf("Unexpected truncation %q", got)
+	}
+}
+
+func TestTable(t *testing.T) {
+	tab := cfmt.NewTable("Site", "Port")
+	tab.SetAlign(1, cfmt.AlignRight)
+	tab.AddRow("%green example.com", 443)
+	tab.AddRow("日本.jp", 80)
+
+	want := "┌─────────────┬──────┐\n" +
+		"│ Site        │ Port │\n" +
+		"├─────────────┼──────┤\n" +
+		"│ example.com │  443 │\n" +
+		"│ 日本.jp     │   80 │\n" +
+		"└─────────────┴──────┘\n"
+	if tab.String() != want {
+		t.Errorf("Got:\n%s\nExpected:\n%s", tab.String(), want)
+	}
+
+	var buf bytes.Buffer
+	tab.SetMaxWidth(12).Fprint(&buf)
+	want = "Site    Port\n" +
+		"examp…   443\n" +
+		"日本.…    80\n"
+	if buf.String() != want {
+		t.Errorf("Got:\n%q\nExpected:\n%q", buf.String(), want)
+	}
+}
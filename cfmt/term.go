@@ -0,0 +1,99 @@
+package cfmt
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Colour modes for SetMode().
+const (
+	// ModeAuto enables colour for terminals, unless NO_COLOR is set.
+	// FORCE_COLOR enables it for anything.
+	ModeAuto = iota
+	// ModeAlways enables colour for every writer.
+	ModeAlways
+	// ModeNever strips all markup.
+	ModeNever
+)
+
+var (
+	modeMu sync.RWMutex
+	mode   = ModeAuto
+)
+
+// SetMode chooses when colour escape codes are written.
+func SetMode(m int) {
+	modeMu.Lock()
+	defer modeMu.Unlock()
+	mode = m
+}
+
+// Mode returns the current colour mode.
+func Mode() int {
+	modeMu.RLock()
+	defer modeMu.RUnlock()
+	return mode
+}
+
+// Enabled returns true if colour escape codes should be written to w.
+func Enabled(w io.Writer) bool {
+	switch Mode() {
+	case ModeAlways:
+		return true
+	case ModeNever:
+		return false
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	fc := os.Getenv("FORCE_COLOR")
+	if fc != "" && fc != "0" && fc != "false" {
+		return true
+	}
+
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	return IsTerminal(w)
+}
+
+// IsTerminal returns true if w is a file connected to a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// TerminalWidth returns the width of the terminal w is connected to, falling back
+// to the COLUMNS environment variable. It returns 0 if w isn't a terminal or the
+// width is unknown.
+func TerminalWidth(w io.Writer) int {
+	if !IsTerminal(w) {
+		return 0
+	}
+
+	n := terminalWidth(w.(*os.File))
+	if n > 0 {
+		return n
+	}
+
+	n, err := strconv.Atoi(os.Getenv("COLUMNS"))
+	if err != nil || n < 0 {
+		return 0
+	}
+
+	return n
+}
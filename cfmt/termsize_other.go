@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package cfmt
+
+import "os"
+
+// terminalWidth is unknown on this platform, so COLUMNS is all we have.
+func terminalWidth(f *os.File) int {
+	return 0
+}
@@ -0,0 +1,158 @@
+package cfmt
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Width returns the number of terminal columns s takes up when printed.
+// ANSI escape sequences take no space, combining marks take none, and wide
+// East Asian characters and emoji take two.
+func Width(s string) int {
+	w := 0
+	for len(s) > 0 {
+		n := escapeLen(s)
+		if n > 0 {
+			s = s[n:]
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s)
+		w += RuneWidth(r)
+		s = s[size:]
+	}
+	return w
+}
+
+// RuneWidth returns the number of columns a rune takes up in a terminal.
+func RuneWidth(r rune) int {
+	switch {
+	case r < 32 || (r >= 0x7f && r < 0xa0):
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case isWide(r):
+		return 2
+	}
+
+	return 1
+}
+
+// wideRanges are the East Asian wide and fullwidth blocks, and emoji.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115f},   // Hangul Jamo
+	{0x2e80, 0x303e},   // CJK radicals to CJK symbols and punctuation
+	{0x3041, 0x33ff},   // Hiragana to CJK compatibility
+	{0x3400, 0x4dbf},   // CJK extension A
+	{0x4e00, 0x9fff},   // CJK unified ideographs
+	{0xa000, 0xa4cf},   // Yi
+	{0xac00, 0xd7a3},   // Hangul syllables
+	{0xf900, 0xfaff},   // CJK compatibility ideographs
+	{0xfe30, 0xfe4f},   // CJK compatibility forms
+	{0xff00, 0xff60},   // Fullwidth forms
+	{0xffe0, 0xffe6},   // Fullwidth signs
+	{0x1f300, 0x1f64f}, // Pictographs and emoticons
+	{0x1f900, 0x1f9ff}, // Supplemental symbols and pictographs
+	{0x20000, 0x3fffd}, // CJK extensions B and up
+}
+
+func isWide(r rune) bool {
+	if r < 0x1100 {
+		return false
+	}
+
+	for _, rng := range wideRanges {
+		if r >= rng[0] && r <= rng[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeLen returns the length of the ANSI escape sequence at the start of s, or 0.
+func escapeLen(s string) int {
+	if len(s) < 2 || s[0] != '\x1b' || s[1] != '[' {
+		return 0
+	}
+
+	for i := 2; i < len(s); i++ {
+		if s[i] >= 0x40 && s[i] <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// Truncate cuts s down to at most w columns, ending with tail (e.g. "…") if
+// anything was cut. Escape sequences are kept intact, and a reset is added if
+// the cut string contained any.
+func Truncate(s string, w int, tail string) string {
+	if Width(s) <= w {
+		return s
+	}
+
+	limit := w - Width(tail)
+	if limit < 0 {
+		limit = 0
+		tail = ""
+	}
+
+	var b strings.Builder
+	used := 0
+	escaped := false
+	for len(s) > 0 {
+		n := escapeLen(s)
+		if n > 0 {
+			b.WriteString(s[:n])
+			s = s[n:]
+			escaped = true
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s)
+		rw := RuneWidth(r)
+		if used+rw > limit {
+			break
+		}
+
+		b.WriteString(s[:size])
+		used += rw
+		s = s[size:]
+	}
+	b.WriteString(tail)
+	if escaped {
+		b.WriteString(Reset)
+	}
+	return b.String()
+}
+
+// PadRight pads s with spaces to w columns.
+func PadRight(s string, w int) string {
+	n := w - Width(s)
+	if n <= 0 {
+		return s
+	}
+
+	return s + strings.Repeat(" ", n)
+}
+
+// PadLeft pads s with leading spaces to w columns.
+func PadLeft(s string, w int) string {
+	n := w - Width(s)
+	if n <= 0 {
+		return s
+	}
+
+	return strings.Repeat(" ", n) + s
+}
+
+// Center pads s on both sides to w columns.
+func Center(s string, w int) string {
+	n := w - Width(s)
+	if n <= 0 {
+		return s
+	}
+
+	return strings.Repeat(" ", n/2) + s + strings.Repeat(" ", n-n/2)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"go/format"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/Urethramancer/cross"
@@ -84,12 +85,7 @@ func (cmd *CmdConfig) Run(in []string) error {
 				switch f.Value {
 				case "string", "float32", "float64", "int", "bool":
 					f.MakeTags(true, false)
-					opt, err := createOption(f, comment)
-					if err != nil {
-						return err
-					}
-
-					list = append(list, opt)
+					list = append(list, createOption(f, comment))
 
 				default:
 					embedded.Add(f.Name, s.Name)
@@ -107,29 +103,25 @@ func (cmd *CmdConfig) Run(in []string) error {
 
 	var src []byte
 
-	pkg.InternalImports = append(pkg.InternalImports, "encoding/json")
-	pkg.InternalImports = append(pkg.InternalImports, "io/ioutil")
-	_, err = config.WriteStrings(
+	pkg.ExternalImports = append(pkg.ExternalImports, "\"github.com/Urethramancer/signor/files\"")
+	sort.Strings(pkg.ExternalImports)
+	pkg.ExternalImports = stringer.RemoveDuplicateStrings(pkg.ExternalImports)
+	config.WriteStrings(
 		"// Package ", pkg.Name,
 		" loads and saves the ", stlist[0],
 		" structure.\n")
+	_, err = pkg.WriteTo(config)
 	if err != nil {
 		return err
 	}
 
-	_, err = config.WriteStrings(pkg.String(), "\n")
-	if err != nil {
-		return err
-	}
+	config.WriteString("\n")
 
 	funcs := strings.ReplaceAll(jsonLoader, "$STRUCT$", stlist[0])
-	_, err = config.WriteString(funcs)
-	if err != nil {
-		return err
-	}
+	config.WriteString(funcs)
 
 	for _, st := range stlist {
-		_, err = commands.WriteStrings(
+		commands.WriteStrings(
 			"type ", st, "GetCommands struct {\n",
 			"\tGet", st,
 			"\tCmdGet", st,
@@ -146,42 +138,21 @@ func (cmd *CmdConfig) Run(in []string) error {
 			"`", "\n", "}\n\n",
 			"// CmdGet", st, " options.\n",
 			"type CmdGet", st, " struct {\n")
-		if err != nil {
-			return err
-		}
-
 		for _, opt := range stmap[st] {
-			_, err = commands.WriteStrings("\t", opt.Name, "\tGet", opt.Name, "\t", opt.Tag, "\n")
-			if err != nil {
-				return err
-			}
+			commands.WriteStrings("\t", opt.Name, "\tGet", opt.Name, "\t", opt.Tag, "\n")
 		}
 
-		_, err = commands.WriteStrings("}\n\n",
+		commands.WriteStrings("}\n\n",
 			"// CmdSet", st, " options.\n",
 			"type CmdSet", st, " struct {\n")
-		if err != nil {
-			return err
-		}
-
 		for _, opt := range stmap[st] {
-			_, err = commands.WriteStrings("\t", opt.Name, "\tSet", opt.Name, "\t", opt.Tag, "\n")
-			if err != nil {
-				return err
-			}
-		}
-		_, err = commands.WriteStrings("}\n\n")
-		if err != nil {
-			return err
+			commands.WriteStrings("\t", opt.Name, "\tSet", opt.Name, "\t", opt.Tag, "\n")
 		}
+		commands.WriteStrings("}\n\n")
 	}
 
 	path := filepath.Join(cmd.Output, cmd.Output+".go")
-	err = printHeader(path)
-	if err != nil {
-		return err
-	}
-
+	printHeader(path)
 	src, err = format.Source([]byte(config.String()))
 	if err != nil {
 		return err
@@ -189,11 +160,7 @@ func (cmd *CmdConfig) Run(in []string) error {
 	m("%s", src)
 
 	path = filepath.Join(cmd.Output, "commands.go")
-	err = printHeader(path)
-	if err != nil {
-		return err
-	}
-
+	printHeader(path)
 	src, err = format.Source([]byte(commands.String()))
 	if err != nil {
 		return err
@@ -201,11 +168,7 @@ func (cmd *CmdConfig) Run(in []string) error {
 	m("%s", src)
 
 	path = filepath.Join(cmd.Output, "handlers.go")
-	err = printHeader(path)
-	if err != nil {
-		return err
-	}
-
+	printHeader(path)
 	src, err = format.Source([]byte(handlers.String()))
 	if err != nil {
 		return err
@@ -214,46 +177,30 @@ func (cmd *CmdConfig) Run(in []string) error {
 	return nil
 }
 
-func printHeader(s string) error {
+func printHeader(s string) {
 	h := stringer.New()
-	_, err := h.WriteStrings(
+	h.WriteStrings(
 		strings.Repeat("*", len(s)+4),
 		"\n* ", s, " *\n",
 		strings.Repeat("*", len(s)+4),
 	)
-	if err != nil {
-		return err
-	}
-
 	log.Default.Msg("%s", h.String())
-	return nil
 }
 
-func createOption(f *structure.Field, comment string) (*cfgOption, error) {
+func createOption(f *structure.Field, comment string) *cfgOption {
 	opt := &cfgOption{
 		Type: f.Value,
 	}
 	t := stringer.New()
 	f.Name = strings.ToLower(f.Name)
-	_, err := t.WriteI("`command:\"", f.Name, "\"")
-	if err != nil {
-		return nil, err
-	}
-
+	t.WriteI("`command:\"", f.Name, "\"")
 	f.Name = strings.Title(f.Name)
 	opt.Name = f.Name
 	if comment != "" {
-		_, err = t.WriteI(" help:", "\"", strings.TrimSpace(comment[2:]), "\"")
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	_, err = t.WriteI(" placeholder:", "\"", strings.ToUpper(f.Name), "\"", "`")
-	if err != nil {
-		return nil, err
+		t.WriteI(" help:", "\"", strings.TrimSpace(comment[2:]), "\"")
 	}
 
+	t.WriteI(" placeholder:", "\"", strings.ToUpper(f.Name), "\"", "`")
 	opt.Tag = t.String()
-	return opt, nil
+	return opt
 }
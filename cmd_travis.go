@@ -1,14 +1,12 @@
 package main
 
 import (
-	"os"
 	"os/exec"
 	"strings"
 
 	"github.com/Urethramancer/signor/files"
 	"github.com/Urethramancer/signor/log"
 	"github.com/Urethramancer/signor/opt"
-	"github.com/Urethramancer/signor/stringer"
 	"github.com/Urethramancer/signor/structure"
 )
 
@@ -19,6 +17,24 @@ type CmdTravis struct {
 	Input []string `help:"Input Go source file to read imports from." placeholder:"SOURCE"`
 }
 
+// travisConfig is the structure of .travis.yml.
+type travisConfig struct {
+	Language string      `yaml:"language"`
+	Go       []string    `yaml:"go"`
+	Install  []string    `yaml:"install,omitempty"`
+	Include  []travisJob `yaml:"include"`
+}
+
+type travisJob struct {
+	OS    string      `yaml:"os"`
+	Go    string      `yaml:"go"`
+	Cache travisCache `yaml:"cache"`
+}
+
+type travisCache struct {
+	Directories []string `yaml:"directories"`
+}
+
 // Run Travis generation.
 func (cmd *CmdTravis) Run(in []string) error {
 	if cmd.Help || len(cmd.Input) == 0 {
@@ -28,35 +44,41 @@ func (cmd *CmdTravis) Run(in []string) error {
 	ver, err := goversion()
 	if err != nil {
 		log.Default.Err("Couldn't run Go: %s", err.Error())
-		os.Exit(2)
+		log.Default.Exit(2)
+		return err
 	}
 
-	yml := stringer.New()
-	yml.WriteStrings("language: go\n\ngo:\n  - ", ver, "\n")
-
 	pkg, err := structure.NewPackage(cmd.Input...)
 	if err != nil {
 		return err
 	}
 
-	yml.WriteStrings("\ninstall:\n")
+	cfg := travisConfig{
+		Language: "go",
+		Go:       []string{ver},
+		Include: []travisJob{{
+			OS: "linux",
+			Go: ver + ".x",
+			Cache: travisCache{
+				Directories: []string{"$HOME/.cache/go-build", "$HOME/gopath/pkg/mod"},
+			},
+		}},
+	}
 	for _, imp := range pkg.MergeExternalImports() {
 		imp = strings.ReplaceAll(imp, "\"", "")
-		yml.WriteStrings("    - go get ", imp, "\n")
+		cfg.Install = append(cfg.Install, "go get "+imp)
 	}
 
-	yml.WriteI("\ninclude:\n  - os: linux\n",
-		"    go: ", '"', ver, ".x", '"', "\n",
-		"    cache:\n      directories:\n",
-		"        - $HOME/.cache/go-build\n",
-		"        - $HOME/gopath/pkg/mod\n",
-	)
+	if cmd.Name != "" {
+		return files.SaveYAML(cmd.Name, cfg)
+	}
 
-	if cmd.Name == "" {
-		log.Default.Msg(yml.String())
-	} else {
-		return files.WriteFile(cmd.Name, []byte(yml.String()))
+	yml, err := files.MarshalYAML(cfg)
+	if err != nil {
+		return err
 	}
+
+	log.Default.Msg(string(yml))
 	return nil
 }
 
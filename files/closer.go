@@ -1,18 +1,39 @@
 package files
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"reflect"
+	"sync"
 
 	"github.com/Urethramancer/signor/log"
 )
 
-// Closer holds open files to close them all in sequence, logging any errors.
+// Closer holds resources to close them all in reverse order of addition, like
+// deferred calls, logging any errors. It's safe for concurrent use, so one
+// Closer can serve as the shutdown registry for a whole program.
 type Closer struct {
-	list []*os.File
+	sync.Mutex
+	list []closerEntry
 	l    *log.Logger
 }
 
-// NewCloser returns a pointer to a closer.
+// closerEntry is a resource with the label used in errors.
+type closerEntry struct {
+	label string
+	c     io.Closer
+}
+
+// closerFunc adapts a function to io.Closer.
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+// NewCloser returns a pointer to a closer for files.
 func NewCloser(files ...*os.File) *Closer {
 	c := &Closer{
 		l: log.Default,
@@ -23,36 +44,113 @@ func NewCloser(files ...*os.File) *Closer {
 	return c
 }
 
+// NewClosers returns a pointer to a closer for any resources.
+func NewClosers(closers ...io.Closer) *Closer {
+	c := NewCloser()
+	return c.AddClosers(closers...)
+}
+
 // SetLogger to an alternative logger.
 func (c *Closer) SetLogger(l *log.Logger) {
+	c.Lock()
+	defer c.Unlock()
 	c.l = l
 }
 
-// AddFile adds a file pointer to the closer's list.
-func (c *Closer) AddFile(f *os.File) *Closer {
-	c.list = append(c.list, f)
+// Add adds a resource to the closer's list. Files are labelled with their name,
+// and anything else with its type. Nil resources are skipped, including nil
+// pointers of any type.
+func (c *Closer) Add(x io.Closer) *Closer {
+	if isNil(x) {
+		return c
+	}
+
+	label := fmt.Sprintf("%T", x)
+	if f, ok := x.(*os.File); ok {
+		label = f.Name()
+	}
+	return c.AddNamed(label, x)
+}
+
+// AddClosers adds any number of resources with Add().
+func (c *Closer) AddClosers(closers ...io.Closer) *Closer {
+	for _, x := range closers {
+		c.Add(x)
+	}
 	return c
 }
 
-// Close and remove all files in the list, and log any errors from the Close() call with or without a timestamp.
-func (c *Closer) Close(t bool) {
-	if len(c.list) == 0 {
-		return
+// AddNamed adds a resource with a label for error messages. Nil is skipped.
+func (c *Closer) AddNamed(label string, x io.Closer) *Closer {
+	if isNil(x) {
+		return c
 	}
 
-	var err error
-	for _, f := range c.list {
-		name := f.Name()
-		err = f.Close()
+	c.Lock()
+	defer c.Unlock()
+	c.list = append(c.list, closerEntry{label: label, c: x})
+	return c
+}
+
+// isNil returns true for nil, and for interfaces holding a nil pointer, map,
+// function, channel or slice, which would panic or fail when closed.
+func isNil(x io.Closer) bool {
+	if x == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(x)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Func, reflect.Chan, reflect.Interface, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// AddFunc adds a shutdown function with a label for error messages. Nil is skipped.
+func (c *Closer) AddFunc(label string, f func() error) *Closer {
+	if f == nil {
+		return c
+	}
+
+	return c.AddNamed(label, closerFunc(f))
+}
+
+// AddFile adds a file pointer to the closer's list.
+func (c *Closer) AddFile(f *os.File) *Closer {
+	return c.Add(f)
+}
+
+// Close and remove all resources in the list, most recently added first.
+// Errors are logged with or without a timestamp, and returned joined.
+func (c *Closer) Close(t bool) error {
+	c.Lock()
+	list := c.list
+	// Clear the list for reuse.
+	c.list = nil
+	l := c.l
+	c.Unlock()
+
+	var errs []error
+	for i := len(list) - 1; i >= 0; i-- {
+		e := list[i]
+		err := e.c.Close()
 		if err != nil {
 			if t {
-				c.l.TErr("Error closing %s: %s", name, err.Error())
+				l.TErr("Error closing %s: %s", e.label, err.Error())
 			} else {
-				c.l.Err("Error closing %s: %s", name, err.Error())
+				l.Err("Error closing %s: %s", e.label, err.Error())
 			}
+			errs = append(errs, fmt.Errorf("%s: %w", e.label, err))
 		}
 	}
+	return errors.Join(errs...)
+}
 
-	// Clear the list for reuse.
-	c.list = []*os.File{}
+// CloseOnExit closes everything in the list when the program exits through
+// the logger's Exit(), for use as a program's shutdown registry.
+func (c *Closer) CloseOnExit(l *log.Logger) {
+	l.OnExit(func() {
+		c.Close(true)
+	})
 }
@@ -0,0 +1,78 @@
+package files_test
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Urethramancer/signor/files"
+	"github.com/Urethramancer/signor/log/logtest"
+)
+
+func TestCloser(t *testing.T) {
+	logs := logtest.SwapDefault(t)
+	f, err := os.Create(filepath.Join(t.TempDir(), "closer.txt"))
+	if err != nil {
+		t.Fatalf("Couldn't create file: %s", err.Error())
+	}
+
+	c := files.NewCloser(f)
+	c.Close(false)
+	logs.AssertNotLogged(t, 1, "Error closing")
+
+	c.AddFile(f)
+	c.Close(false)
+	logs.AssertLogged(t, 1, "Error closing")
+}
+
+func TestCloserOrder(t *testing.T) {
+	logs := logtest.SwapDefault(t)
+	var order []string
+	c := files.NewCloser()
+	c.AddFunc("first", func() error {
+		order = append(order, "first")
+		return nil
+	})
+	c.AddFunc("second", func() error {
+		order = append(order, "second")
+		return errors.New("failed")
+	})
+
+	err := c.Close(false)
+	if len(order) != 2 || order[0] != "second" || order[1] != "first" {
+		t.Errorf("Expected LIFO order, got %v", order)
+	}
+	if err == nil || err.Error() != "second: failed" {
+		t.Errorf("Expected labelled error, got %v", err)
+	}
+	logs.AssertLogged(t, 1, "Error closing second: failed")
+}
+
+func TestClosers(t *testing.T) {
+	logtest.SwapDefault(t)
+	f, err := os.Create(filepath.Join(t.TempDir(), "closers.txt"))
+	if err != nil {
+		t.Fatalf("Couldn't create file: %s", err.Error())
+	}
+
+	list := []*os.File{f}
+	c := files.NewCloser(list...)
+	var nilFile *os.File
+	var nilConn *net.TCPConn
+	var nilPipe *io.PipeReader
+	c.Add(nil).Add(nilFile).AddFunc("nil", nil).AddClosers(nil)
+	c.Add(nilConn).AddNamed("pipe", nilPipe).AddClosers(nilConn, nilPipe)
+	err = c.Close(false)
+	if err != nil {
+		t.Errorf("Nil resources weren't skipped: %s", err.Error())
+	}
+
+	r, w := io.Pipe()
+	err = files.NewClosers(r, w).Close(false)
+	if err != nil {
+		t.Errorf("Couldn't close pipe: %s", err.Error())
+	}
+}
@@ -0,0 +1,57 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnknownFormat is returned by LoadConfig and SaveConfig for unsupported extensions.
+var ErrUnknownFormat = errors.New("unknown configuration file format")
+
+// LoadConfig loads a configuration file into a structure, picking the format
+// from the extension: .json, .ini, .yaml/.yml or .toml.
+// JSON files are loaded with LoadJSONConfig.
+func LoadConfig(fn string, out interface{}) error {
+	return LoadConfigFS(OS, fn, out)
+}
+
+// LoadConfigFS loads a configuration file from a filesystem like LoadConfig.
+func LoadConfigFS(fsys fs.FS, fn string, out interface{}) error {
+	switch strings.ToLower(filepath.Ext(fn)) {
+	case ".json":
+		return LoadJSONConfigFS(fsys, fn, out)
+	case ".ini":
+		return LoadINIConfigFS(fsys, fn, out)
+	case ".yaml", ".yml":
+		return LoadYAMLFS(fsys, fn, out)
+	case ".toml":
+		return LoadTOMLFS(fsys, fn, out)
+	}
+
+	return fmt.Errorf("%s: %w", fn, ErrUnknownFormat)
+}
+
+// SaveConfig saves a structure as a configuration file, picking the format
+// from the extension like LoadConfig.
+func SaveConfig(fn string, data interface{}) error {
+	return SaveConfigFS(OS, fn, data)
+}
+
+// SaveConfigFS saves a configuration file to a filesystem like SaveConfig.
+func SaveConfigFS(fsys WriteFS, fn string, data interface{}) error {
+	switch strings.ToLower(filepath.Ext(fn)) {
+	case ".json":
+		return SaveJSONFS(fsys, fn, data)
+	case ".ini":
+		return SaveINIConfigFS(fsys, fn, data)
+	case ".yaml", ".yml":
+		return SaveYAMLFS(fsys, fn, data)
+	case ".toml":
+		return SaveTOMLFS(fsys, fn, data)
+	}
+
+	return fmt.Errorf("%s: %w", fn, ErrUnknownFormat)
+}
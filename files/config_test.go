@@ -0,0 +1,42 @@
+package files_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/Urethramancer/signor/files"
+)
+
+type formatConfig struct {
+	Name  string   `json:"name" ini:"name" yaml:"name" toml:"name"`
+	Port  int      `json:"port" ini:"port" yaml:"port" toml:"port"`
+	Hosts []string `json:"hosts" ini:"hosts" yaml:"hosts" toml:"hosts"`
+}
+
+func TestConfigFormats(t *testing.T) {
+	dir := t.TempDir()
+	in := formatConfig{Name: "test", Port: 8080, Hosts: []string{"a", "b"}}
+	for _, ext := range []string{".json", ".ini", ".yaml", ".yml", ".toml"} {
+		fn := filepath.Join(dir, "config"+ext)
+		err := files.SaveConfig(fn, in)
+		if err != nil {
+			t.Fatalf("Couldn't save %s: %s", fn, err.Error())
+		}
+
+		var out formatConfig
+		err = files.LoadConfig(fn, &out)
+		if err != nil {
+			t.Fatalf("Couldn't load %s: %s", fn, err.Error())
+		}
+
+		if out.Name != in.Name || out.Port != in.Port || len(out.Hosts) != 2 || out.Hosts[1] != "b" {
+			t.Errorf("%s: expected %+v, got %+v", ext, in, out)
+		}
+	}
+
+	err := files.LoadConfig(filepath.Join(dir, "config.xml"), &in)
+	if !errors.Is(err, files.ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
@@ -40,18 +40,6 @@ func FileExists(path string) bool {
 	return true
 }
 
-// WriteFile saves a file with private permissions.
-func WriteFile(path string, data []byte) error {
-	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
-		return err
-	}
-
-	defer NewCloser(out).Close(true)
-	_, err = out.Write(data)
-	return err
-}
-
 // EnsureDirExists by creating it.
 func EnsureDirExists(path string) error {
 	if !DirExists(path) {
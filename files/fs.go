@@ -0,0 +1,79 @@
+package files
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// WriteFS is a filesystem which files can be saved to.
+type WriteFS interface {
+	fs.FS
+	// WriteFile replaces the named file with data.
+	WriteFile(name string, data []byte) error
+}
+
+// OS is the operating system's filesystem. Unlike os.DirFS() it takes any path
+// the os package does, including absolute and relative paths, and it writes
+// with WriteFile.
+var OS WriteFS = osFS{}
+
+type osFS struct{}
+
+// IsOS returns true for the OS filesystem, which uses OS paths rather than
+// slash-separated ones.
+func IsOS(fsys fs.FS) bool {
+	_, ok := fsys.(osFS)
+	return ok
+}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) WriteFile(name string, data []byte) error {
+	return WriteFile(name, data)
+}
+
+// joinFS resolves name relative to the directory of base, with OS paths for
+// the OS filesystem and slash-separated paths for anything else.
+func joinFS(fsys fs.FS, base, name string) string {
+	if IsOS(fsys) {
+		if filepath.IsAbs(name) {
+			return name
+		}
+
+		return filepath.Join(filepath.Dir(base), name)
+	}
+
+	if path.IsAbs(name) {
+		return path.Clean(name[1:])
+	}
+
+	return path.Join(path.Dir(base), name)
+}
+
+// absFS returns a name which is the same for every way of referring to a file.
+func absFS(fsys fs.FS, name string) string {
+	if IsOS(fsys) {
+		abs, err := filepath.Abs(name)
+		if err == nil {
+			return abs
+		}
+	}
+
+	return path.Clean(name)
+}
@@ -0,0 +1,45 @@
+package files_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/Urethramancer/signor/files"
+)
+
+// memFS is a writable filesystem in memory.
+type memFS struct {
+	fstest.MapFS
+}
+
+func (m memFS) WriteFile(name string, data []byte) error {
+	m.MapFS[name] = &fstest.MapFile{Data: data, Mode: 0600}
+	return nil
+}
+
+func TestConfigFS(t *testing.T) {
+	fsys := memFS{fstest.MapFS{
+		"conf/base.json": {Data: []byte(`{"port": 8080}`)},
+		"conf/app.json":  {Data: []byte("{\n\t\"$include\": \"base.json\",\n\t\"name\": \"app\"\n}")},
+	}}
+
+	var cfg formatConfig
+	err := files.LoadConfigFS(fsys, "conf/app.json", &cfg)
+	if err != nil {
+		t.Fatalf("Couldn't load: %s", err.Error())
+	}
+	if cfg.Name != "app" || cfg.Port != 8080 {
+		t.Errorf("Wrong values: %+v", cfg)
+	}
+
+	err = files.SaveConfigFS(fsys, "conf/app.toml", cfg)
+	if err != nil {
+		t.Fatalf("Couldn't save: %s", err.Error())
+	}
+
+	var out formatConfig
+	err = files.LoadConfigFS(fsys, "conf/app.toml", &out)
+	if err != nil || out.Name != "app" || out.Port != 8080 {
+		t.Errorf("Round trip failed: %+v (%v)", out, err)
+	}
+}
@@ -2,7 +2,10 @@ package files
 
 import (
 	"bufio"
-	"os"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"strconv"
 	"strings"
 
 	"github.com/Urethramancer/signor/stringer"
@@ -10,10 +13,12 @@ import (
 
 // INI file base structure.
 type INI struct {
-	// Sections with settings.
+	// Sections with settings. Keys before the first section header are in the section named "".
 	Sections map[string]*INISection
 	// Order sections were loaded or added in.
 	Order []string
+	// Footer holds comment lines after the last field.
+	Footer []string
 }
 
 const (
@@ -36,54 +41,274 @@ func NewINI() *INI {
 
 // LoadINI from file and take a guess at the types of each value.
 func LoadINI(filename string) (*INI, error) {
-	f, err := os.Open(filename)
+	return LoadINIFS(OS, filename)
+}
+
+// LoadINIFS loads an INI file from a filesystem and takes a guess at the types of each value.
+func LoadINIFS(fsys fs.FS, filename string) (*INI, error) {
+	data, err := fs.ReadFile(fsys, filename)
 	if err != nil {
 		return nil, err
 	}
 
-	defer f.Close()
+	ini, err := ParseINI(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", filename, err)
+	}
+
+	return ini, nil
+}
+
+// ParseINI parses INI data and takes a guess at the types of each value.
+//
+// Values can be bare or quoted with double quotes, which support the escapes
+// \n, \t, \r, \\ and \". Quoted values are always strings. Bare values are
+// bools (true, false, yes, no), ints, floats or strings, in that order.
+// Comments start with ; or #, either on their own line or after a value.
+// A line ending in a backslash continues on the next line.
+func ParseINI(data []byte) (*INI, error) {
 	ini := NewINI()
-	r := bufio.NewReader(f)
-	loop := true
-	for loop {
-		l, err := r.ReadString('\n')
+	var sec *INISection
+	var comments []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	n := 0
+	for sc.Scan() {
+		n++
+		start := n
+		line := strings.TrimRight(sc.Text(), "\r")
+		for strings.HasSuffix(line, "\\") && !strings.HasSuffix(line, "\\\\") && sc.Scan() {
+			n++
+			next := strings.TrimSpace(strings.TrimRight(sc.Text(), "\r"))
+			line = line[:len(line)-1] + next
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			continue
+		case trimmed[0] == ';' || trimmed[0] == '#':
+			comments = append(comments, trimmed)
+			continue
+		case trimmed[0] == '[':
+			end := strings.IndexByte(trimmed, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%d: unterminated section header", start)
+			}
+
+			rest := strings.TrimSpace(trimmed[end+1:])
+			if rest != "" && rest[0] != ';' && rest[0] != '#' {
+				return nil, fmt.Errorf("%d: unexpected text after section header", start)
+			}
+
+			name := strings.TrimSpace(trimmed[1:end])
+			sec = ini.Section(name)
+			if sec == nil {
+				sec = ini.AddSection(name)
+			}
+			sec.Comment = append(sec.Comment, comments...)
+			sec.InlineComment = rest
+			comments = nil
+			continue
+		}
+
+		a := strings.SplitN(trimmed, "=", 2)
+		if len(a) != 2 {
+			return nil, fmt.Errorf("%d: expected key=value", start)
+		}
+
+		key := strings.TrimSpace(a[0])
+		if key == "" {
+			return nil, fmt.Errorf("%d: missing key", start)
+		}
+
+		f, err := parseINIValue(strings.TrimSpace(a[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%d: %w", start, err)
+		}
+
+		if sec == nil {
+			sec = ini.AddSection("")
+		}
+		f.Comment = comments
+		comments = nil
+		sec.set(key, f)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	ini.Footer = comments
+	return ini, nil
+}
+
+// parseINIValue splits off any inline comment and infers the type.
+func parseINIValue(s string) (*INIField, error) {
+	f := &INIField{}
+	if strings.HasPrefix(s, "\"") {
+		v, rest, err := unquoteINI(s)
 		if err != nil {
-			loop = false
-		} else {
-			l = l[:len(l)-1]
-			// This automatically skips comments, and really anything else
-			// unknown that isn't after the first section header.
-			if strings.HasPrefix(l, "[") && strings.HasSuffix(l, "]") {
-				name := l[1 : len(l)-1]
-				s := ini.AddSection(name)
-				s.parse(r)
+			return nil, err
+		}
+
+		rest = strings.TrimSpace(rest)
+		if rest != "" && rest[0] != ';' && rest[0] != '#' {
+			return nil, fmt.Errorf("unexpected text after quoted value")
+		}
+
+		f.SetString("", v)
+		f.InlineComment = rest
+		return f, nil
+	}
+
+	for i := 0; i < len(s); i++ {
+		if (s[i] == ';' || s[i] == '#') && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t') {
+			f.InlineComment = s[i:]
+			s = strings.TrimSpace(s[:i])
+			break
+		}
+	}
+
+	switch strings.ToLower(s) {
+	case "yes", "true", "no", "false":
+		f.SetBool("", boolValue(strings.ToLower(s)))
+		f.Value = s
+		return f, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err == nil {
+		f.SetInt("", i)
+		f.Value = s
+		return f, nil
+	}
+
+	if strings.ContainsAny(s, ".eE") {
+		x, err := strconv.ParseFloat(s, 64)
+		if err == nil {
+			f.SetFloat("", x)
+			f.Value = s
+			return f, nil
+		}
+	}
+
+	f.SetString("", s)
+	return f, nil
+}
+
+// unquoteINI reads a double-quoted string with escapes, returning the value and the rest of the line.
+func unquoteINI(s string) (string, string, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			return b.String(), s[i+1:], nil
+		case '\\':
+			i++
+			if i == len(s) {
+				return "", "", fmt.Errorf("unterminated escape")
 			}
+
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '\\', '"', '\'', ';', '#':
+				b.WriteByte(s[i])
+			default:
+				return "", "", fmt.Errorf("unknown escape \\%c", s[i])
+			}
+		default:
+			b.WriteByte(c)
 		}
 	}
-	return ini, err
+	return "", "", fmt.Errorf("unterminated quoted value")
+}
+
+// quoteINI quotes a string value if it wouldn't survive being saved bare,
+// including strings which would be loaded as another type.
+func quoteINI(s string) string {
+	plain := s != "" && s == strings.TrimSpace(s) && !strings.ContainsAny(s, ";#\"\\\n\r\t")
+	if plain {
+		f, err := parseINIValue(s)
+		plain = err == nil && f.Type == INIString
+	}
+	if plain {
+		return s
+	}
+
+	r := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n", "\t", "\\t", "\r", "\\r")
+	return "\"" + r.Replace(s) + "\""
 }
 
 // Save outputs the INI to a file.
 // If tabbed is true, the fields will be saved with a tab character prepended.
 func (ini *INI) Save(filename string, tabbed bool) error {
+	return ini.SaveFS(OS, filename, tabbed)
+}
+
+// SaveFS outputs the INI to a file in a filesystem.
+func (ini *INI) SaveFS(fsys WriteFS, filename string, tabbed bool) error {
+	return fsys.WriteFile(filename, []byte(ini.Format(tabbed)))
+}
+
+// Format returns the INI as text, with comments where they were loaded.
+// If tabbed is true, the fields will have a tab character prepended.
+func (ini *INI) Format(tabbed bool) string {
 	b := stringer.New()
 	count := 0
 	for _, secname := range ini.Order {
+		sec := ini.Sections[secname]
+		if secname == "" && len(sec.Order) == 0 {
+			continue
+		}
+
 		if count > 0 {
 			b.WriteString("\n")
 		}
 		count++
-		b.WriteStrings("[", secname, "]\n")
-		for _, key := range ini.Sections[secname].Order {
-			f := ini.Sections[secname].Fields[key]
-			if tabbed {
-				b.WriteStrings("\t", key, "=", f.Value, "\n")
-			} else {
-				b.WriteStrings(key, "=", f.Value, "\n")
+		for _, c := range sec.Comment {
+			b.WriteStrings(c, "\n")
+		}
+		if secname != "" {
+			b.WriteStrings("[", secname, "]")
+			if sec.InlineComment != "" {
+				b.WriteStrings(" ", sec.InlineComment)
 			}
+			b.WriteString("\n")
+		}
+		for _, key := range sec.Order {
+			f := sec.Fields[key]
+			for _, c := range f.Comment {
+				if tabbed && secname != "" {
+					b.WriteString("\t")
+				}
+				b.WriteStrings(c, "\n")
+			}
+			if tabbed && secname != "" {
+				b.WriteString("\t")
+			}
+			v := f.Value
+			if f.Type == INIString {
+				v = quoteINI(v)
+			}
+			b.WriteStrings(key, "=", v)
+			if f.InlineComment != "" {
+				b.WriteStrings(" ", f.InlineComment)
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(ini.Footer) > 0 {
+		b.WriteString("\n")
+		for _, c := range ini.Footer {
+			b.WriteStrings(c, "\n")
 		}
 	}
-	return WriteFile(filename, []byte(b.String()))
+	return b.String()
 }
 
 // AddSection to INI structure.
@@ -96,52 +321,19 @@ func (ini *INI) AddSection(name string) *INISection {
 	return sec
 }
 
-// parse section properties until a new section or end of file.
-func (s *INISection) parse(r *bufio.Reader) {
-	loop := true
-	for loop {
-		next, err := r.Peek(2)
-		// EOF
-		if err != nil {
-			return
-		}
-
-		// Skip blank lines
-		if next[0] == '\n' {
-			return
-		}
-
-		// New section, so this one's done
-		if next[0] == '[' || next[1] == '[' {
-			return
-		}
-
-		p, err := r.ReadString('\n')
-		if err != nil {
-			return
-		}
-
-		// Skip comments
-		if strings.HasPrefix(p, "#") || strings.HasPrefix(p, ";") {
-			continue
-		}
-
-		a := strings.SplitN(p, "=", 2)
-		if a == nil || len(a) != 2 {
-			return
-		}
-
-		a[0] = strings.TrimSpace(a[0])
-		a[1] = strings.TrimSpace(a[1])
-		switch a[1] {
-		case "yes", "true", "no", "false":
-			s.AddBool(a[0], boolValue(a[1]))
-			return
-		}
+// Section returns the named section, or nil if it doesn't exist.
+// Fields before any section header are in the section named "".
+func (ini *INI) Section(name string) *INISection {
+	return ini.Sections[name]
+}
 
-		// TODO: Figure out ints and floats.
-		s.AddString(a[0], a[1])
+// set adds a field, or replaces an existing one in place.
+func (s *INISection) set(key string, f *INIField) {
+	_, ok := s.Fields[key]
+	if !ok {
+		s.Order = append(s.Order, key)
 	}
+	s.Fields[key] = f
 }
 
 // boolValue from common strings.
@@ -168,8 +360,7 @@ func (s *INISection) GetBool(key string, alt bool) bool {
 func (s *INISection) AddBool(key string, value bool) {
 	f := INIField{}
 	f.SetBool(key, value)
-	s.Fields[key] = &f
-	s.Order = append(s.Order, key)
+	s.set(key, &f)
 }
 
 // GetInt returns a field as an int, or the alternative.
@@ -186,17 +377,21 @@ func (s *INISection) GetInt(key string, alt int) int {
 func (s *INISection) AddInt(key string, value int) {
 	f := INIField{}
 	f.SetInt(key, value)
-	s.Fields[key] = &f
-	s.Order = append(s.Order, key)
+	s.set(key, &f)
 }
 
 // GetFloat returns a field as a float64, or the alternative.
+// Int fields are converted.
 func (s *INISection) GetFloat(key string, alt float64) float64 {
 	v, ok := s.Fields[key]
 	if !ok {
 		return alt
 	}
 
+	if v.Type == INIInt {
+		return float64(v.intV)
+	}
+
 	return v.floatV
 }
 
@@ -204,8 +399,7 @@ func (s *INISection) GetFloat(key string, alt float64) float64 {
 func (s *INISection) AddFloat(key string, value float64) {
 	f := INIField{}
 	f.SetFloat(key, value)
-	s.Fields[key] = &f
-	s.Order = append(s.Order, key)
+	s.set(key, &f)
 }
 
 // GetString returns a field as a string, or the alternative.
@@ -222,6 +416,5 @@ func (s *INISection) GetString(key, alt string) string {
 func (s *INISection) AddString(key string, value string) {
 	f := INIField{}
 	f.SetString(key, value)
-	s.Fields[key] = &f
-	s.Order = append(s.Order, key)
+	s.set(key, &f)
 }
@@ -1,13 +1,22 @@
 package files
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // INIField contains a variable and its data.
 type INIField struct {
 	// Value will be stripped of surrounding whitespace when loaded.
 	Value string
 	// Type lets the user choose which Get* method to use when loading unknown files.
-	Type   byte
+	Type byte
+	// Comment lines above the field, including the ; or # marker.
+	Comment []string
+	// InlineComment after the value, including the marker.
+	InlineComment string
+
 	boolV  bool
 	intV   int
 	floatV float64
@@ -46,7 +55,11 @@ func (f *INIField) GetFloat(key string) float64 {
 func (f *INIField) SetFloat(key string, value float64) {
 	f.floatV = value
 	f.Type = INIFloat
-	f.Value = fmt.Sprintf("%f", value)
+	f.Value = strconv.FormatFloat(value, 'f', -1, 64)
+	// Keep whole numbers recognisable as floats when loaded again.
+	if !strings.ContainsAny(f.Value, ".eEIN") {
+		f.Value += ".0"
+	}
 }
 
 // SetString sets a field as a string.
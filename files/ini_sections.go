@@ -5,4 +5,8 @@ type INISection struct {
 	Fields map[string]*INIField
 	// Order fields were loaded or added in.
 	Order []string
+	// Comment lines above the section header, including the ; or # marker.
+	Comment []string
+	// InlineComment after the section header, including the marker.
+	InlineComment string
 }
@@ -0,0 +1,414 @@
+package files
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrINITarget is returned when unmarshalling into anything but a pointer to a struct.
+	ErrINITarget = errors.New("ini: target must be a non-nil pointer to a struct")
+	// ErrINISource is returned when marshalling anything but a struct or pointer to one.
+	ErrINISource = errors.New("ini: source must be a struct or a pointer to one")
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// LoadINIConfig loads an INI file into a structure, like LoadJSON, including
+// defaults and validation.
+func LoadINIConfig(fn string, out interface{}) error {
+	return LoadINIConfigFS(OS, fn, out)
+}
+
+// LoadINIConfigFS loads an INI file from a filesystem into a structure.
+func LoadINIConfigFS(fsys fs.FS, fn string, out interface{}) error {
+	data, err := fs.ReadFile(fsys, fn)
+	if err != nil {
+		return err
+	}
+
+	return loadStruct(out, func() error {
+		err := UnmarshalINI(data, out)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+
+		return nil
+	})
+}
+
+// SaveINIConfig saves a structure as an INI file, like SaveJSON.
+func SaveINIConfig(path string, data interface{}) error {
+	return SaveINIConfigFS(OS, path, data)
+}
+
+// SaveINIConfigFS saves a structure as an INI file in a filesystem.
+func SaveINIConfigFS(fsys WriteFS, path string, data interface{}) error {
+	b, err := MarshalINI(data)
+	if err != nil {
+		return err
+	}
+
+	return fsys.WriteFile(path, b)
+}
+
+// UnmarshalINI parses INI data into the structure v points to.
+//
+// Fields are matched by their `ini:"name"` tag, or the field name if untagged,
+// and `ini:"-"` skips a field. Fields of the top-level structure are keys before
+// any section. Nested structures become sections, named like keys, and structures
+// nested in those become sections joined with dots, e.g. [server.timeouts].
+//
+// Supported types are strings, bools, ints, uints, floats, time.Duration
+// ("1m30s", or a plain number of seconds), anything implementing
+// encoding.TextUnmarshaler, pointers to these, and slices of them as
+// comma-separated lists. Fields for keys missing from the data are left alone;
+// LoadINIConfig() fills them from `default:"..."` tags with SetDefaults().
+func UnmarshalINI(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrINITarget
+	}
+
+	ini, err := ParseINI(data)
+	if err != nil {
+		return err
+	}
+
+	return unmarshalSection(ini, "", rv.Elem())
+}
+
+// unmarshalSection fills the structure from the named section and its children.
+func unmarshalSection(ini *INI, name string, st reflect.Value) error {
+	sec := ini.Section(name)
+	t := st.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key, _, skip := iniFieldName(sf)
+		if skip {
+			continue
+		}
+
+		fv := st.Field(i)
+		if isINISection(sf.Type) {
+			if fv.Kind() == reflect.Ptr {
+				if ini.Section(joinSection(name, key)) == nil && !hasSubsections(ini, joinSection(name, key)) {
+					continue
+				}
+				if fv.IsNil() {
+					fv.Set(reflect.New(sf.Type.Elem()))
+				}
+				fv = fv.Elem()
+			}
+			err := unmarshalSection(ini, joinSection(name, key), fv)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := "", false
+		if sec != nil {
+			var f *INIField
+			f, ok = sec.Fields[key]
+			if ok {
+				raw = f.Value
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		err := setINIValue(fv, raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", joinSection(name, key), err)
+		}
+	}
+	return nil
+}
+
+// hasSubsections returns true if any section is nested under name.
+func hasSubsections(ini *INI, name string) bool {
+	for _, s := range ini.Order {
+		if strings.HasPrefix(s, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// setINIValue converts a raw value to the field's type.
+func setINIValue(v reflect.Value, raw string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setINIValue(v.Elem(), raw)
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			secs, serr := strconv.ParseFloat(raw, 64)
+			if serr != nil {
+				return err
+			}
+
+			d = time.Duration(secs * float64(time.Second))
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		switch strings.ToLower(raw) {
+		case "yes", "true", "on", "1":
+			v.SetBool(true)
+		case "no", "false", "off", "0", "":
+			v.SetBool(false)
+		default:
+			return fmt.Errorf("invalid bool %q", raw)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var parts []string
+		if strings.TrimSpace(raw) != "" {
+			parts = strings.Split(raw, ",")
+		}
+		s := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			err := setINIValue(s.Index(i), strings.TrimSpace(p))
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// MarshalINI returns the INI encoding of a structure, using the same rules as UnmarshalINI.
+func MarshalINI(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrINISource
+	}
+
+	ini := NewINI()
+	err := marshalSection(ini, "", rv)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(ini.Format(false)), nil
+}
+
+// marshalSection adds the structure's fields to the named section, and nested
+// structures as sections after it.
+func marshalSection(ini *INI, name string, st reflect.Value) error {
+	sec := ini.Section(name)
+	if sec == nil {
+		sec = ini.AddSection(name)
+	}
+
+	t := st.Type()
+	var nested []int
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key, omitempty, skip := iniFieldName(sf)
+		if skip {
+			continue
+		}
+
+		fv := st.Field(i)
+		if isINISection(sf.Type) {
+			nested = append(nested, i)
+			continue
+		}
+
+		if omitempty && fv.IsZero() {
+			continue
+		}
+
+		err := addINIValue(sec, key, fv)
+		if err != nil {
+			return fmt.Errorf("%s: %w", joinSection(name, key), err)
+		}
+	}
+
+	for _, i := range nested {
+		key, _, _ := iniFieldName(t.Field(i))
+		fv := st.Field(i)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		err := marshalSection(ini, joinSection(name, key), fv)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addINIValue adds a field with the type closest to the value's.
+func addINIValue(sec *INISection, key string, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+
+		sec.AddString(key, string(b))
+		return nil
+	}
+
+	if v.Type() == durationType {
+		sec.AddString(key, time.Duration(v.Int()).String())
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		sec.AddString(key, v.String())
+	case reflect.Bool:
+		sec.AddBool(key, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		sec.AddInt(key, int(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// Values above the int range keep their text, which UnmarshalINI reads back.
+		f := INIField{}
+		f.SetInt(key, int(v.Uint()))
+		f.Value = strconv.FormatUint(v.Uint(), 10)
+		sec.set(key, &f)
+	case reflect.Float32, reflect.Float64:
+		sec.AddFloat(key, v.Float())
+	case reflect.Slice:
+		list := make([]string, v.Len())
+		for i := range list {
+			s, err := iniText(v.Index(i))
+			if err != nil {
+				return err
+			}
+
+			if strings.Contains(s, ",") {
+				return fmt.Errorf("list element %q contains a comma", s)
+			}
+			list[i] = s
+		}
+		f := INIField{}
+		f.SetString(key, strings.Join(list, ","))
+		sec.set(key, &f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// iniText formats a single list element.
+func iniText(v reflect.Value) (string, error) {
+	sec := &INISection{Fields: make(map[string]*INIField)}
+	err := addINIValue(sec, "x", v)
+	if err != nil {
+		return "", err
+	}
+
+	return sec.GetString("x", ""), nil
+}
+
+// iniFieldName returns the key for a struct field, whether it has omitempty,
+// and whether it should be skipped.
+func iniFieldName(sf reflect.StructField) (string, bool, bool) {
+	if sf.PkgPath != "" {
+		return "", false, true
+	}
+
+	tag := sf.Tag.Get("ini")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	a := strings.Split(tag, ",")
+	name := a[0]
+	if name == "" {
+		name = sf.Name
+	}
+
+	omitempty := false
+	for _, o := range a[1:] {
+		if o == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
+
+// isINISection returns true for struct types which should become sections.
+func isINISection(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	return !reflect.PtrTo(t).Implements(textUnmarshalerType) && !t.Implements(textMarshalerType)
+}
+
+// joinSection builds a dotted section name.
+func joinSection(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+
+	return parent + "." + name
+}
@@ -0,0 +1,102 @@
+package files_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Urethramancer/signor/files"
+)
+
+type webConfig struct {
+	Port     int           `ini:"port" default:"8080"`
+	Hosts    []string      `ini:"hosts"`
+	Timeout  time.Duration `ini:"timeout" default:"30s"`
+	Secure   bool          `ini:"secure"`
+	Internal string        `ini:"-"`
+}
+
+type testConfig struct {
+	Name  string    `ini:"name"`
+	Ratio float64   `ini:"ratio"`
+	Web   webConfig `ini:"web"`
+}
+
+func TestUnmarshalINI(t *testing.T) {
+	data := []byte("name = \"test app\"\nratio = 0.5\n\n[web]\nhosts = a.com, b.com\nsecure = yes\n")
+	var cfg testConfig
+	err := files.UnmarshalINI(data, &cfg)
+	if err != nil {
+		t.Fatalf("Couldn't unmarshal: %s", err.Error())
+	}
+
+	if cfg.Name != "test app" || cfg.Ratio != 0.5 {
+		t.Errorf("Wrong top-level values: %+v", cfg)
+	}
+	if cfg.Web.Port != 0 || cfg.Web.Timeout != 0 {
+		t.Errorf("UnmarshalINI() shouldn't apply defaults: %+v", cfg.Web)
+	}
+	if len(cfg.Web.Hosts) != 2 || cfg.Web.Hosts[1] != "b.com" || !cfg.Web.Secure {
+		t.Errorf("Wrong section values: %+v", cfg.Web)
+	}
+}
+
+func TestLoadINIConfigPreset(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "config.ini")
+	writeTestFile(t, fn, "name = app\n\n[web]\nsecure = yes\n")
+	cfg := testConfig{Web: webConfig{Port: 9000}}
+	err := files.LoadINIConfig(fn, &cfg)
+	if err != nil {
+		t.Fatalf("Couldn't load: %s", err.Error())
+	}
+
+	if cfg.Web.Port != 9000 || cfg.Web.Timeout != 30*time.Second || !cfg.Web.Secure {
+		t.Errorf("Expected the preset port and default timeout, got %+v", cfg.Web)
+	}
+}
+
+func TestMarshalINIRoundTrip(t *testing.T) {
+	in := testConfig{
+		Name:  "round trip",
+		Ratio: 2,
+		Web:   webConfig{Port: 443, Hosts: []string{"x", "y"}, Timeout: 90 * time.Second, Internal: "hidden"},
+	}
+	data, err := files.MarshalINI(&in)
+	if err != nil {
+		t.Fatalf("Couldn't marshal: %s", err.Error())
+	}
+
+	var out testConfig
+	err = files.UnmarshalINI(data, &out)
+	if err != nil {
+		t.Fatalf("Couldn't unmarshal:\n%s\n%s", data, err.Error())
+	}
+
+	in.Web.Internal = ""
+	if out.Name != in.Name || out.Ratio != in.Ratio || out.Web.Port != in.Web.Port ||
+		out.Web.Timeout != in.Web.Timeout || len(out.Web.Hosts) != 2 {
+		t.Errorf("Round trip mismatch:\n%s\n%+v", data, out)
+	}
+}
+
+func TestMarshalINIUint64(t *testing.T) {
+	type big struct {
+		Max  uint64   `ini:"max"`
+		List []uint64 `ini:"list"`
+	}
+	in := big{Max: 1 << 63, List: []uint64{1, 1<<64 - 1}}
+	data, err := files.MarshalINI(&in)
+	if err != nil {
+		t.Fatalf("Couldn't marshal: %s", err.Error())
+	}
+
+	var out big
+	err = files.UnmarshalINI(data, &out)
+	if err != nil {
+		t.Fatalf("Couldn't unmarshal:\n%s\n%s", data, err.Error())
+	}
+
+	if out.Max != in.Max || len(out.List) != 2 || out.List[1] != in.List[1] {
+		t.Errorf("Round trip mismatch:\n%s\n%+v", data, out)
+	}
+}
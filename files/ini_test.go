@@ -0,0 +1,90 @@
+package files_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Urethramancer/signor/files"
+)
+
+const testINI = `; Top-level settings
+name = signor
+debug = yes
+
+; Web server
+[web] ; inline
+port = 8080
+ratio = 0.75
+
+enabled = false
+title = "Hello; \"world\"" ; greeting
+path = /usr/local/\
+  share
+count = "42"
+# Trailing
+`
+
+func TestINI(t *testing.T) {
+	ini, err := files.ParseINI([]byte(testINI))
+	if err != nil {
+		t.Fatalf("Couldn't parse: %s", err.Error())
+	}
+
+	top := ini.Section("")
+	if top.GetString("name", "") != "signor" || !top.GetBool("debug", false) {
+		t.Errorf("Top-level keys not loaded: %v", top.Order)
+	}
+
+	web := ini.Section("web")
+	if web == nil {
+		t.Fatalf("Section web missing")
+	}
+
+	if web.GetInt("port", 0) != 8080 {
+		t.Errorf("Expected port 8080, got %d", web.GetInt("port", 0))
+	}
+
+	if web.GetFloat("ratio", 0) != 0.75 {
+		t.Errorf("Expected ratio 0.75, got %f", web.GetFloat("ratio", 0))
+	}
+
+	if web.GetBool("enabled", true) {
+		t.Errorf("Fields after a blank line were dropped")
+	}
+
+	if web.GetString("title", "") != `Hello; "world"` {
+		t.Errorf("Unexpected title %q", web.GetString("title", ""))
+	}
+
+	if web.GetString("path", "") != "/usr/local/share" {
+		t.Errorf("Unexpected continued path %q", web.GetString("path", ""))
+	}
+
+	if web.Fields["count"].Type != files.INIString {
+		t.Errorf("Quoted numbers should be strings")
+	}
+
+	fn := filepath.Join(t.TempDir(), "test.ini")
+	err = ini.Save(fn, false)
+	if err != nil {
+		t.Fatalf("Couldn't save: %s", err.Error())
+	}
+
+	again, err := files.LoadINI(fn)
+	if err != nil {
+		t.Fatalf("Couldn't load saved file: %s", err.Error())
+	}
+
+	if again.Format(false) != ini.Format(false) {
+		t.Errorf("Round trip changed the file:\n%s\n---\n%s", ini.Format(false), again.Format(false))
+	}
+
+	if again.Section("web").Fields["ratio"].Comment != nil || len(again.Section("web").Comment) != 1 || again.Footer[0] != "# Trailing" {
+		t.Errorf("Comments were lost:\n%s", again.Format(false))
+	}
+
+	_, err = files.ParseINI([]byte("[broken\n"))
+	if err == nil {
+		t.Errorf("Expected an error for an unterminated section header")
+	}
+}
@@ -2,21 +2,35 @@ package files
 
 import (
 	"encoding/json"
-	"io/ioutil"
+	"io/fs"
 )
 
 // LoadJSON and unmarshal structure.
+// Structures get defaults from tags before loading and are validated after, see SetDefaults() and Validate().
 func LoadJSON(fn string, out interface{}) error {
-	f, err := ioutil.ReadFile(fn)
+	return LoadJSONFS(OS, fn, out)
+}
+
+// LoadJSONFS loads JSON from a filesystem and unmarshals the structure.
+// Defaults and validation work as in LoadJSON().
+func LoadJSONFS(fsys fs.FS, fn string, out interface{}) error {
+	f, err := fs.ReadFile(fsys, fn)
 	if err != nil {
 		return err
 	}
 
-	return json.Unmarshal(f, out)
+	return loadStruct(out, func() error {
+		return json.Unmarshal(f, out)
+	})
 }
 
 // SaveJSON after marshalling neatly.
 func SaveJSON(path string, data interface{}) error {
+	return SaveJSONFS(OS, path, data)
+}
+
+// SaveJSONFS marshals neatly and saves to a filesystem.
+func SaveJSONFS(fsys WriteFS, path string, data interface{}) error {
 	var b []byte
 	var err error
 	b, err = json.MarshalIndent(data, "", "\t")
@@ -24,5 +38,5 @@ func SaveJSON(path string, data interface{}) error {
 		return err
 	}
 
-	return WriteFile(path, b)
+	return fsys.WriteFile(path, b)
 }
@@ -0,0 +1,346 @@
+package files
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// LoadJSONConfig loads a hand-written JSON configuration file into a structure.
+// On top of plain JSON it supports:
+//   - // line and /* block */ comments.
+//   - ${VAR} in strings, replaced by the environment variable, which must be set.
+//     ${VAR:-default} uses the default if it's unset or empty, and $${ is a literal ${.
+//   - "$include": "other.json" in an object, which merges in the object from another
+//     file relative to this one. Keys in the including object take precedence, and
+//     the value may also be a list of files, merged in order.
+//
+// Errors report the file and line, e.g. "config.json:12: ...". Structures get
+// defaults and validation as in LoadJSON().
+func LoadJSONConfig(fn string, out interface{}) error {
+	return LoadJSONConfigFS(OS, fn, out)
+}
+
+// LoadJSONConfigFS loads a hand-written JSON configuration file from a filesystem.
+// Includes are relative to the including file in the same filesystem.
+func LoadJSONConfigFS(fsys fs.FS, fn string, out interface{}) error {
+	return loadStruct(out, func() error {
+		return loadJSONConfig(fsys, fn, out)
+	})
+}
+
+func loadJSONConfig(fsys fs.FS, fn string, out interface{}) error {
+	ld := &jsonConfigLoader{fsys: fsys, pos: make(map[string]string)}
+	tree, err := ld.file(fn, "")
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(data, out)
+	var ute *json.UnmarshalTypeError
+	if errors.As(err, &ute) {
+		pos, ok := ld.pos[ute.Field]
+		if !ok {
+			pos = fn
+		}
+		return fmt.Errorf("%s: %s: cannot use %s as %s", pos, ute.Field, ute.Value, ute.Type)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// jsonConfigLoader builds a merged tree from a file and its includes.
+type jsonConfigLoader struct {
+	fsys fs.FS
+	// pos maps dotted key paths to the "file:line" they were set at.
+	pos map[string]string
+	// stack of files being loaded, to catch include loops.
+	stack []string
+}
+
+// jsonInclude is an include found while reading an object.
+type jsonInclude struct {
+	v    interface{}
+	line int
+}
+
+// file loads a file as the value at path.
+func (ld *jsonConfigLoader) file(fn, path string) (interface{}, error) {
+	abs := absFS(ld.fsys, fn)
+	for _, s := range ld.stack {
+		if s == abs {
+			return nil, fmt.Errorf("%s: include loop", fn)
+		}
+	}
+
+	ld.stack = append(ld.stack, abs)
+	defer func() { ld.stack = ld.stack[:len(ld.stack)-1] }()
+
+	raw, err := fs.ReadFile(ld.fsys, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := preprocessJSON(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := ld.value(dec, data, fn, path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = dec.Token()
+	if err != io.EOF {
+		return nil, fmt.Errorf("%s:%d: unexpected data after top-level value", fn, lineAt(data, dec.InputOffset()))
+	}
+
+	return v, nil
+}
+
+// value reads one value and everything in it.
+func (ld *jsonConfigLoader) value(dec *json.Decoder, data []byte, fn, path string) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, jsonPosError(err, data, fn, dec)
+	}
+
+	d, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	if d == '[' {
+		list := []interface{}{}
+		for dec.More() {
+			v, err := ld.value(dec, data, fn, path)
+			if err != nil {
+				return nil, err
+			}
+
+			list = append(list, v)
+		}
+		_, err = dec.Token()
+		if err != nil {
+			return nil, jsonPosError(err, data, fn, dec)
+		}
+
+		return list, nil
+	}
+
+	obj := make(map[string]interface{})
+	var includes []jsonInclude
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, jsonPosError(err, data, fn, dec)
+		}
+
+		key := tok.(string)
+		line := lineAt(data, dec.InputOffset())
+		sub := joinSection(path, key)
+		v, err := ld.value(dec, data, fn, sub)
+		if err != nil {
+			return nil, err
+		}
+
+		if key == "$include" {
+			includes = append(includes, jsonInclude{v: v, line: line})
+			continue
+		}
+
+		obj[key] = v
+		if _, ok := ld.pos[sub]; !ok {
+			ld.pos[sub] = fmt.Sprintf("%s:%d", fn, line)
+		}
+	}
+	_, err = dec.Token()
+	if err != nil {
+		return nil, jsonPosError(err, data, fn, dec)
+	}
+
+	for _, inc := range includes {
+		var names []string
+		switch x := inc.v.(type) {
+		case string:
+			names = []string{x}
+		case []interface{}:
+			for _, n := range x {
+				s, ok := n.(string)
+				if !ok {
+					return nil, fmt.Errorf("%s:%d: $include list must contain file names", fn, inc.line)
+				}
+				names = append(names, s)
+			}
+		default:
+			return nil, fmt.Errorf("%s:%d: $include must be a file name or list of them", fn, inc.line)
+		}
+
+		for _, name := range names {
+			name = joinFS(ld.fsys, fn, name)
+			v, err := ld.file(name, path)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: $include: %w", fn, inc.line, err)
+			}
+
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s:%d: $include %s: not an object", fn, inc.line, name)
+			}
+
+			mergeJSON(obj, m)
+		}
+	}
+	return obj, nil
+}
+
+// mergeJSON copies keys from src which dst doesn't have, merging nested objects.
+func mergeJSON(dst, src map[string]interface{}) {
+	for k, v := range src {
+		old, ok := dst[k]
+		if !ok {
+			dst[k] = v
+			continue
+		}
+
+		a, ok1 := old.(map[string]interface{})
+		b, ok2 := v.(map[string]interface{})
+		if ok1 && ok2 {
+			mergeJSON(a, b)
+		}
+	}
+}
+
+// jsonPosError adds the file and line to a decoding error.
+func jsonPosError(err error, data []byte, fn string, dec *json.Decoder) error {
+	var se *json.SyntaxError
+	if errors.As(err, &se) {
+		return fmt.Errorf("%s:%d: %s", fn, lineAt(data, se.Offset), se.Error())
+	}
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%s:%d: unexpected end of file", fn, lineAt(data, dec.InputOffset()))
+	}
+
+	return fmt.Errorf("%s: %w", fn, err)
+}
+
+// lineAt returns the line number of an offset.
+func lineAt(data []byte, off int64) int {
+	if off > int64(len(data)) {
+		off = int64(len(data))
+	}
+	return bytes.Count(data[:off], []byte{'\n'}) + 1
+}
+
+// preprocessJSON removes comments and substitutes environment variables in strings.
+// Newlines are kept, so line numbers stay the same.
+func preprocessJSON(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	line := 1
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if c == '\n' {
+			line++
+		}
+
+		if inString {
+			switch {
+			case c == '\\' && i+1 < len(data):
+				out = append(out, c, data[i+1])
+				i++
+			case c == '"':
+				inString = false
+				out = append(out, c)
+			case c == '$' && strings.HasPrefix(string(data[i:]), "$${"):
+				out = append(out, '$', '{')
+				i += 2
+			case c == '$' && i+1 < len(data) && data[i+1] == '{':
+				end := bytes.IndexByte(data[i:], '}')
+				nl := bytes.IndexByte(data[i:], '\n')
+				if end < 0 || (nl >= 0 && nl < end) {
+					return nil, fmt.Errorf("%d: unterminated ${", line)
+				}
+
+				v, err := expandJSONVar(string(data[i+2 : i+end]))
+				if err != nil {
+					return nil, fmt.Errorf("%d: %w", line, err)
+				}
+
+				out = append(out, v...)
+				i += end
+			default:
+				out = append(out, c)
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				line++
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			start := line
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return nil, fmt.Errorf("%d: unterminated comment", start)
+			}
+
+			for _, b := range data[i : i+2+end+2] {
+				if b == '\n' {
+					line++
+					out = append(out, '\n')
+				}
+			}
+			i += 2 + end + 1
+		default:
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// expandJSONVar looks up VAR or VAR:-default, returning the value escaped for a JSON string.
+func expandJSONVar(s string) ([]byte, error) {
+	name, def, hasDef := strings.Cut(s, ":-")
+	v, ok := os.LookupEnv(name)
+	if hasDef && v == "" {
+		v, ok = def, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return b[1 : len(b)-1], nil
+}
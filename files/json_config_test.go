@@ -0,0 +1,87 @@
+package files_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Urethramancer/signor/files"
+)
+
+type jsonTimeouts struct {
+	Read  int `json:"read"`
+	Write int `json:"write"`
+}
+
+type jsonConfig struct {
+	Name     string       `json:"name"`
+	Home     string       `json:"home"`
+	Mode     string       `json:"mode"`
+	Literal  string       `json:"literal"`
+	Timeouts jsonTimeouts `json:"timeouts"`
+}
+
+func writeTestFile(t *testing.T, fn, s string) {
+	err := os.WriteFile(fn, []byte(s), 0600)
+	if err != nil {
+		t.Fatalf("Couldn't write %s: %s", fn, err.Error())
+	}
+}
+
+func TestLoadJSONConfig(t *testing.T) {
+	t.Setenv("SIGNOR_TEST_HOME", "/home/\"test\"")
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "base.json"), `{
+	"name": "base",
+	"timeouts": { "read": 5, "write": 10 }
+}`)
+	fn := filepath.Join(dir, "config.json")
+	writeTestFile(t, fn, `// Main configuration.
+{
+	"$include": "base.json",
+	/* The name
+	   from base is replaced. */
+	"name": "main", // Inline comment.
+	"home": "${SIGNOR_TEST_HOME}",
+	"mode": "${SIGNOR_TEST_UNSET:-dev}",
+	"literal": "$${HOME} // not a comment",
+	"timeouts": { "read": 1 }
+}`)
+
+	var cfg jsonConfig
+	err := files.LoadJSONConfig(fn, &cfg)
+	if err != nil {
+		t.Fatalf("Couldn't load: %s", err.Error())
+	}
+
+	if cfg.Name != "main" || cfg.Home != "/home/\"test\"" || cfg.Mode != "dev" || cfg.Literal != "${HOME} // not a comment" {
+		t.Errorf("Wrong values: %+v", cfg)
+	}
+	if cfg.Timeouts.Read != 1 || cfg.Timeouts.Write != 10 {
+		t.Errorf("Includes not merged: %+v", cfg.Timeouts)
+	}
+}
+
+func TestLoadJSONConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		data   string
+		expect string
+	}{
+		{"{\n\t\"name\": \"${SIGNOR_TEST_UNSET}\"\n}", "bad.json:2: environment variable SIGNOR_TEST_UNSET is not set"},
+		{"{\n\t\"name\": \"x\",\n\t\"timeouts\": {\n\t\t\"read\": \"slow\"\n\t}\n}", "bad.json:4: timeouts.read:"},
+		{"{\n\t\"name\": \"x\"\n\t\"mode\": \"y\"\n}", "bad.json:3:"},
+		{"{\n\t\"$include\": \"missing.json\"\n}", "bad.json:2: $include:"},
+		{"{\n\t\"$include\": \"bad.json\"\n}", "include loop"},
+	}
+	fn := filepath.Join(dir, "bad.json")
+	for _, tc := range tests {
+		writeTestFile(t, fn, tc.data)
+		var cfg jsonConfig
+		err := files.LoadJSONConfig(fn, &cfg)
+		if err == nil || !strings.Contains(err.Error(), tc.expect) {
+			t.Errorf("Expected error containing %q, got %v", tc.expect, err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrLocked is returned when a lock couldn't be taken before the timeout.
+	ErrLocked = errors.New("file is locked")
+	// ErrAlreadyRunning is returned by WritePIDFile when another live process holds the PID file.
+	ErrAlreadyRunning = errors.New("already running")
+)
+
+// lockRetry is how often a lock with a timeout is retried.
+const lockRetry = 10 * time.Millisecond
+
+// Lock is an advisory lock on a file. Advisory locks only keep out processes
+// which also lock the file, and are released if the process dies.
+type Lock struct {
+	f *os.File
+}
+
+// LockFile takes an exclusive lock on a file, creating it if necessary.
+// A timeout of 0 tries once, and a negative timeout waits forever.
+func LockFile(path string, timeout time.Duration) (*Lock, error) {
+	return lockFile(path, true, timeout)
+}
+
+// RLockFile takes a shared lock on a file, creating it if necessary.
+// Any number of processes can hold shared locks while nobody holds an exclusive one.
+func RLockFile(path string, timeout time.Duration) (*Lock, error) {
+	return lockFile(path, false, timeout)
+}
+
+func lockFile(path string, exclusive bool, timeout time.Duration) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if os.IsPermission(err) && !exclusive {
+		f, err = os.Open(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err = tryLock(f, exclusive)
+		if err == nil {
+			return &Lock{f: f}, nil
+		}
+
+		if !errors.Is(err, ErrLocked) || (timeout >= 0 && !time.Now().Before(deadline)) {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		time.Sleep(lockRetry)
+	}
+}
+
+// File returns the locked file.
+func (l *Lock) File() *os.File {
+	return l.f
+}
+
+// Unlock releases the lock and closes the file.
+func (l *Lock) Unlock() error {
+	err := unlock(l.f)
+	if err != nil {
+		l.f.Close()
+		return err
+	}
+
+	return l.f.Close()
+}
+
+// PIDFile holds the process ID of a running program, locked for as long as it runs.
+type PIDFile struct {
+	path string
+	lock *Lock
+}
+
+// WritePIDFile writes the current process ID to a file and keeps it locked.
+// If another live process holds the file, the error wraps ErrAlreadyRunning.
+// PIDs left behind by processes which died without removing the file are replaced.
+func WritePIDFile(path string) (*PIDFile, error) {
+	l, err := lockPIDFile(path)
+	if errors.Is(err, ErrLocked) {
+		pid, _ := ReadPIDFile(path)
+		return nil, fmt.Errorf("%w with PID %d", ErrAlreadyRunning, pid)
+	}
+
+	if errors.Is(err, errors.ErrUnsupported) {
+		// No locking, so check whether the PID is alive.
+		pid, perr := ReadPIDFile(path)
+		if perr == nil && pid != os.Getpid() && processAlive(pid) {
+			return nil, fmt.Errorf("%w with PID %d", ErrAlreadyRunning, pid)
+		}
+
+		err = os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644)
+		if err != nil {
+			return nil, err
+		}
+
+		return &PIDFile{path: path}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	f := l.File()
+	err = f.Truncate(0)
+	if err == nil {
+		_, err = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		l.Unlock()
+		return nil, err
+	}
+
+	return &PIDFile{path: path, lock: l}, nil
+}
+
+// lockPIDFile locks the file currently linked at path. Remove() unlinks the file
+// before unlocking it, so a lock taken on a file which has since been replaced
+// is dropped and taken again on the new one.
+func lockPIDFile(path string) (*Lock, error) {
+	for {
+		l, err := LockFile(path, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		locked, err := l.File().Stat()
+		if err != nil {
+			l.Unlock()
+			return nil, err
+		}
+
+		linked, err := os.Stat(path)
+		if err == nil && os.SameFile(locked, linked) {
+			return l, nil
+		}
+
+		l.Unlock()
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+}
+
+// ReadPIDFile returns the process ID stored in a file.
+func ReadPIDFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// Remove deletes the PID file and releases its lock. The file is unlinked while
+// still locked, so nobody can take the lock on it after it's gone.
+func (p *PIDFile) Remove() error {
+	err := os.Remove(p.path)
+	if p.lock != nil {
+		uerr := p.lock.Unlock()
+		if err == nil {
+			err = uerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,35 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !plan9
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!plan9
+
+package files
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+func tryLock(f *os.File, exclusive bool) error {
+	return errors.ErrUnsupported
+}
+
+func unlock(f *os.File) error {
+	return errors.ErrUnsupported
+}
+
+// processAlive returns true if a process with the PID exists.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	// Finding a process only fails on Windows, and elsewhere it has to be signalled.
+	if runtime.GOOS == "windows" {
+		return true
+	}
+
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, os.ErrPermission)
+}
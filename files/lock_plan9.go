@@ -0,0 +1,23 @@
+//go:build plan9
+// +build plan9
+
+package files
+
+import (
+	"errors"
+	"os"
+	"strconv"
+)
+
+func tryLock(f *os.File, exclusive bool) error {
+	return errors.ErrUnsupported
+}
+
+func unlock(f *os.File) error {
+	return errors.ErrUnsupported
+}
+
+// processAlive returns true if a process with the PID exists.
+func processAlive(pid int) bool {
+	return Exists("/proc/" + strconv.Itoa(pid))
+}
@@ -0,0 +1,73 @@
+package files_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Urethramancer/signor/files"
+)
+
+func TestLockFile(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "lock")
+	r1, err := files.RLockFile(fn, 0)
+	if err != nil {
+		t.Fatalf("Couldn't take shared lock: %s", err.Error())
+	}
+
+	r2, err := files.RLockFile(fn, 0)
+	if err != nil {
+		t.Fatalf("Couldn't take second shared lock: %s", err.Error())
+	}
+
+	_, err = files.LockFile(fn, 20*time.Millisecond)
+	if !errors.Is(err, files.ErrLocked) {
+		t.Errorf("Expected ErrLocked, got %v", err)
+	}
+
+	r1.Unlock()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		r2.Unlock()
+	}()
+	l, err := files.LockFile(fn, time.Second)
+	if err != nil {
+		t.Fatalf("Couldn't take exclusive lock after release: %s", err.Error())
+	}
+
+	l.Unlock()
+}
+
+func TestPIDFile(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "app.pid")
+	// A PID left behind by a dead process is replaced.
+	err := os.WriteFile(fn, []byte("999999999\n"), 0644)
+	if err != nil {
+		t.Fatalf("Couldn't write stale PID: %s", err.Error())
+	}
+
+	p, err := files.WritePIDFile(fn)
+	if err != nil {
+		t.Fatalf("Couldn't write PID file: %s", err.Error())
+	}
+
+	pid, err := files.ReadPIDFile(fn)
+	if err != nil || pid != os.Getpid() {
+		t.Errorf("Expected PID %d, got %d (%v)", os.Getpid(), pid, err)
+	}
+
+	_, err = files.WritePIDFile(fn)
+	if !errors.Is(err, files.ErrAlreadyRunning) {
+		t.Errorf("Expected ErrAlreadyRunning, got %v", err)
+	}
+
+	err = p.Remove()
+	if err != nil {
+		t.Errorf("Couldn't remove PID file: %s", err.Error())
+	}
+	if files.Exists(fn) {
+		t.Errorf("PID file %s still exists", fn)
+	}
+}
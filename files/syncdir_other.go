@@ -0,0 +1,9 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package files
+
+// syncDir does nothing where directories can't be synced.
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,44 @@
+package files
+
+import (
+	"bytes"
+	"io/fs"
+
+	"github.com/BurntSushi/toml"
+)
+
+// LoadTOML and unmarshal structure.
+// Structures get their `default` tags before decoding and are checked against
+// their `validate` tags after, like LoadJSON().
+func LoadTOML(fn string, out interface{}) error {
+	return LoadTOMLFS(OS, fn, out)
+}
+
+// LoadTOMLFS loads TOML from a filesystem and unmarshals the structure,
+// with the same defaults and validation as LoadTOML().
+func LoadTOMLFS(fsys fs.FS, fn string, out interface{}) error {
+	f, err := fs.ReadFile(fsys, fn)
+	if err != nil {
+		return err
+	}
+
+	return loadStruct(out, func() error {
+		return toml.Unmarshal(f, out)
+	})
+}
+
+// SaveTOML after marshalling.
+func SaveTOML(path string, data interface{}) error {
+	return SaveTOMLFS(OS, path, data)
+}
+
+// SaveTOMLFS marshals and saves to a filesystem.
+func SaveTOMLFS(fsys WriteFS, path string, data interface{}) error {
+	var buf bytes.Buffer
+	err := toml.NewEncoder(&buf).Encode(data)
+	if err != nil {
+		return err
+	}
+
+	return fsys.WriteFile(path, buf.Bytes())
+}
@@ -0,0 +1,275 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotStruct is returned by SetDefaults and Validate for anything but structures.
+var ErrNotStruct = errors.New("not a pointer to a struct")
+
+// ValidationError is a field which broke a rule in its `validate:"..."` tag.
+type ValidationError struct {
+	// Field is the dotted path to the field, using JSON or INI names where tagged.
+	Field string
+	// Message describes the broken rule, e.g. "must be >= 1".
+	Message string
+}
+
+// Error returns the field and message, e.g. "timeouts.read: must be >= 1".
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// SetDefaults sets every zero field of the structure v points to to the value in
+// its `default:"..."` tag, recursing into nested structures. Values are parsed like
+// INI values, so lists are comma-separated and durations look like "1m30s".
+// Every loader applies defaults through it, so fields set before loading are kept.
+func SetDefaults(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrNotStruct
+	}
+
+	return setDefaults(rv.Elem(), "")
+}
+
+func setDefaults(st reflect.Value, path string) error {
+	t := st.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		fv := st.Field(i)
+		name := joinSection(path, tagName(sf))
+		def, ok := sf.Tag.Lookup("default")
+		if ok && fv.IsZero() {
+			err := setINIValue(fv, def)
+			if err != nil {
+				return fmt.Errorf("%s: bad default: %w", name, err)
+			}
+			continue
+		}
+
+		if fv.Kind() == reflect.Ptr && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		if isINISection(fv.Type()) && fv.Kind() == reflect.Struct {
+			err := setDefaults(fv, name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the structure v points to against the rules in its fields'
+// `validate:"..."` tags, recursing into nested structures and slices of them.
+// All broken rules are returned joined, each as a *ValidationError.
+//
+// Rules are separated by commas:
+//   - required: the field must not be the zero value.
+//   - min=N and max=N: numbers and durations must be in range, and strings,
+//     slices and maps must have a length in range.
+//   - oneof=a b c: the value must be one of the space-separated choices.
+//
+// Rules other than required also apply to zero values.
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return ErrNotStruct
+	}
+
+	var errs []error
+	validateStruct(rv, "", &errs)
+	return errors.Join(errs...)
+}
+
+func validateStruct(st reflect.Value, path string, errs *[]error) {
+	t := st.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		fv := st.Field(i)
+		name := joinSection(path, tagName(sf))
+		rules, ok := sf.Tag.Lookup("validate")
+		if ok {
+			for _, rule := range strings.Split(rules, ",") {
+				msg := checkRule(fv, strings.TrimSpace(rule))
+				if msg != "" {
+					*errs = append(*errs, &ValidationError{Field: name, Message: msg})
+					break
+				}
+			}
+		}
+
+		validateNested(fv, name, errs)
+	}
+}
+
+// validateNested recurses into structures, pointers to them and slices of them.
+func validateNested(v reflect.Value, name string, errs *[]error) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			validateNested(v.Elem(), name, errs)
+		}
+	case reflect.Struct:
+		if isINISection(v.Type()) {
+			validateStruct(v, name, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		if !holdsStructs(v.Type().Elem()) {
+			return
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			validateNested(v.Index(i), fmt.Sprintf("%s[%d]", name, i), errs)
+		}
+	}
+}
+
+// holdsStructs returns true for structure types, or pointers, slices and arrays of them.
+func holdsStructs(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return isINISection(t)
+}
+
+// checkRule returns a message if the value breaks the rule.
+func checkRule(v reflect.Value, rule string) string {
+	key, arg, _ := strings.Cut(rule, "=")
+	switch key {
+	case "":
+		return ""
+	case "required":
+		if v.IsZero() {
+			return "is required"
+		}
+		return ""
+	case "oneof":
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return ""
+			}
+			v = v.Elem()
+		}
+		s := fmt.Sprint(v.Interface())
+		choices := strings.Fields(arg)
+		for _, c := range choices {
+			if s == c {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(choices, ", ")
+	case "min", "max":
+		return checkRange(v, key == "min", arg)
+	}
+
+	return fmt.Sprintf("unknown rule %q", key)
+}
+
+// checkRange compares a number, duration or length against a limit.
+func checkRange(v reflect.Value, min bool, arg string) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	op, noun := "<=", ""
+	if min {
+		op = ">="
+	}
+
+	var x, limit float64
+	var err error
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x = float64(v.Int())
+		if v.Type() == durationType {
+			var d time.Duration
+			d, err = time.ParseDuration(arg)
+			limit = float64(d)
+			break
+		}
+		limit, err = strconv.ParseFloat(arg, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x = float64(v.Uint())
+		limit, err = strconv.ParseFloat(arg, 64)
+	case reflect.Float32, reflect.Float64:
+		x = v.Float()
+		limit, err = strconv.ParseFloat(arg, 64)
+	case reflect.String:
+		x = float64(len([]rune(v.String())))
+		limit, err = strconv.ParseFloat(arg, 64)
+		noun = " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		x = float64(v.Len())
+		limit, err = strconv.ParseFloat(arg, 64)
+		noun = " items"
+	default:
+		return fmt.Sprintf("can't check range of %s", v.Type())
+	}
+	if err != nil {
+		return fmt.Sprintf("bad limit %q", arg)
+	}
+
+	if (min && x < limit) || (!min && x > limit) {
+		if noun == "" {
+			return fmt.Sprintf("must be %s %s", op, arg)
+		}
+		if min {
+			return fmt.Sprintf("must have at least %s%s", arg, noun)
+		}
+		return fmt.Sprintf("must have at most %s%s", arg, noun)
+	}
+	return ""
+}
+
+// tagName returns a field's name in the first of its json, ini, yaml or toml tags,
+// or the field name.
+func tagName(sf reflect.StructField) string {
+	for _, key := range []string{"json", "ini", "yaml", "toml"} {
+		name, _, _ := strings.Cut(sf.Tag.Get(key), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// loadStruct sets defaults on structures before decoding, and validates them after.
+// Anything else is just decoded.
+func loadStruct(out interface{}, decode func() error) error {
+	rv := reflect.ValueOf(out)
+	isStruct := rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct
+	if isStruct {
+		err := SetDefaults(out)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := decode()
+	if err != nil || !isStruct {
+		return err
+	}
+
+	return Validate(out)
+}
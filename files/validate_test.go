@@ -0,0 +1,139 @@
+package files_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Urethramancer/signor/files"
+)
+
+type validTimeouts struct {
+	Read  int           `json:"read" validate:"min=1"`
+	Idle  time.Duration `json:"idle" default:"30s" validate:"max=1m"`
+	Retry []int         `json:"retry" default:"1,2,4"`
+}
+
+type validConfig struct {
+	Name     string        `json:"name" validate:"required"`
+	Mode     string        `json:"mode" default:"dev" validate:"oneof=dev prod"`
+	Port     int           `json:"port" default:"8080" validate:"min=1,max=65535"`
+	Tags     []string      `json:"tags" validate:"max=2"`
+	Timeouts validTimeouts `json:"timeouts"`
+}
+
+func TestSetDefaults(t *testing.T) {
+	cfg := validConfig{Port: 80}
+	err := files.SetDefaults(&cfg)
+	if err != nil {
+		t.Fatalf("Couldn't set defaults: %s", err.Error())
+	}
+
+	if cfg.Mode != "dev" || cfg.Port != 80 || cfg.Timeouts.Idle != 30*time.Second || len(cfg.Timeouts.Retry) != 3 {
+		t.Errorf("Wrong defaults: %+v", cfg)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := validConfig{Mode: "test", Port: 70000, Tags: []string{"a", "b", "c"}}
+	err := files.Validate(&cfg)
+	expect := []string{
+		"name: is required",
+		"mode: must be one of dev, prod",
+		"port: must be <= 65535",
+		"tags: must have at most 2 items",
+		"timeouts.read: must be >= 1",
+	}
+	if err == nil {
+		t.Fatalf("Expected validation errors")
+	}
+
+	for _, e := range expect {
+		if !strings.Contains(err.Error(), e) {
+			t.Errorf("Expected %q in:\n%s", e, err.Error())
+		}
+	}
+
+	var ve *files.ValidationError
+	if !errors.As(err, &ve) {
+		t.Errorf("Expected *ValidationError, got %T", err)
+	}
+}
+
+func TestLoadValidates(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "config.json")
+	writeTestFile(t, fn, `{"name": "app", "timeouts": {"read": 0}}`)
+	var cfg validConfig
+	err := files.LoadJSON(fn, &cfg)
+	if err == nil || err.Error() != "timeouts.read: must be >= 1" {
+		t.Errorf("Expected timeouts.read error, got %v", err)
+	}
+
+	writeTestFile(t, fn, `{"name": "app", "port": 443, "timeouts": {"read": 5}}`)
+	cfg = validConfig{}
+	err = files.LoadJSON(fn, &cfg)
+	if err != nil {
+		t.Fatalf("Couldn't load: %s", err.Error())
+	}
+	if cfg.Port != 443 || cfg.Mode != "dev" {
+		t.Errorf("Wrong values: %+v", cfg)
+	}
+}
+
+func TestValidateSlices(t *testing.T) {
+	cfg := struct {
+		Data   []byte
+		Groups [][]*validTimeouts `json:"groups"`
+	}{
+		Data:   make([]byte, 1<<16),
+		Groups: [][]*validTimeouts{{{Read: 1}, {Read: 0}}},
+	}
+	err := files.Validate(&cfg)
+	if err == nil || err.Error() != "groups[0][1].read: must be >= 1" {
+		t.Errorf("Expected groups[0][1].read error, got %v", err)
+	}
+}
+
+type presetConfig struct {
+	Name string `json:"name" yaml:"name" toml:"name" ini:"name" validate:"required"`
+	Port int    `json:"port" yaml:"port" toml:"port" ini:"port" default:"8080"`
+	Mode string `json:"mode" yaml:"mode" toml:"mode" ini:"mode" default:"dev"`
+}
+
+func TestLoadersKeepPresets(t *testing.T) {
+	dir := t.TempDir()
+	data := map[string]string{
+		"json": `{"name": "app"}`,
+		"yaml": "name: app\n",
+		"toml": "name = \"app\"\n",
+		"ini":  "name = app\n",
+	}
+	loaders := []struct {
+		name string
+		ext  string
+		load func(string, interface{}) error
+	}{
+		{"LoadJSON", "json", files.LoadJSON},
+		{"LoadJSONConfig", "json", files.LoadJSONConfig},
+		{"LoadYAML", "yaml", files.LoadYAML},
+		{"LoadTOML", "toml", files.LoadTOML},
+		{"LoadINIConfig", "ini", files.LoadINIConfig},
+		{"LoadConfig", "ini", files.LoadConfig},
+	}
+	for _, l := range loaders {
+		fn := filepath.Join(dir, "config."+l.ext)
+		writeTestFile(t, fn, data[l.ext])
+		cfg := presetConfig{Port: 9000}
+		err := l.load(fn, &cfg)
+		if err != nil {
+			t.Errorf("%s: couldn't load: %s", l.name, err.Error())
+			continue
+		}
+
+		if cfg != (presetConfig{Name: "app", Port: 9000, Mode: "dev"}) {
+			t.Errorf("%s: expected the preset port and default mode, got %+v", l.name, cfg)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package files
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Op is a set of changes to a watched file.
+type Op uint8
+
+// Watch operations. Debounced events may combine several.
+const (
+	// Create means the file appeared, including by being renamed into place.
+	Create Op = 1 << iota
+	// Write means the contents changed.
+	Write
+	// Remove means the file was deleted.
+	Remove
+	// Rename means the file was renamed away. The polling watcher reports this as Remove.
+	Rename
+)
+
+// String returns the operations separated by "|".
+func (op Op) String() string {
+	var a []string
+	for i, s := range []string{"CREATE", "WRITE", "REMOVE", "RENAME"} {
+		if op&(1<<i) != 0 {
+			a = append(a, s)
+		}
+	}
+	return strings.Join(a, "|")
+}
+
+// Event describes changes to a watched file.
+type Event struct {
+	// Name of the file, joined with the watched directory if a directory was added.
+	Name string
+	// Op holds all changes since the last event for the file.
+	Op Op
+}
+
+// DefaultDebounce is how long a watched file must be quiet before its event is sent.
+const DefaultDebounce = 100 * time.Millisecond
+
+// DefaultPollInterval is how often the polling watcher checks for changes.
+const DefaultPollInterval = time.Second
+
+// ErrWatcherClosed is returned when adding paths to a closed watcher.
+var ErrWatcherClosed = errors.New("watcher closed")
+
+// backend delivers raw events for all entries in the directories it watches.
+type backend interface {
+	add(dir string) error
+	remove(dir string) error
+	close() error
+}
+
+// Watcher reports changes to files and directories. Events are debounced, so an
+// editor saving a file in several steps, or an atomic save with WriteFile,
+// results in one event once the file has been quiet for the debounce period.
+//
+// Files are watched through their directory, which means watches survive the
+// file being deleted and recreated or replaced by a rename.
+type Watcher struct {
+	sync.Mutex
+	// Events receives debounced changes.
+	Events chan Event
+	// Errors receives problems from the backend. Errors are dropped if nobody reads them.
+	Errors chan error
+
+	debounce time.Duration
+	// dirs maps watched directories to the names watched in them, with "" meaning all.
+	dirs    map[string]map[string]bool
+	backend backend
+	raw     chan Event
+	done    chan struct{}
+	closed  bool
+}
+
+// NewWatcher returns a watcher using inotify on Linux, falling back to polling
+// every DefaultPollInterval elsewhere or if inotify can't be used.
+// A debounce of 0 or less uses DefaultDebounce.
+func NewWatcher(debounce time.Duration) *Watcher {
+	w := newWatcher(debounce)
+	b, err := newNativeBackend(w.raw, w.error)
+	if err != nil {
+		b = newPoller(DefaultPollInterval, w.raw, w.error)
+	}
+	w.backend = b
+	go w.run()
+	return w
+}
+
+// NewPollingWatcher returns a watcher which checks for changes every interval.
+// Use it for filesystems where inotify doesn't work, such as network mounts.
+func NewPollingWatcher(debounce, interval time.Duration) *Watcher {
+	w := newWatcher(debounce)
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	w.backend = newPoller(interval, w.raw, w.error)
+	go w.run()
+	return w
+}
+
+func newWatcher(debounce time.Duration) *Watcher {
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	return &Watcher{
+		Events:   make(chan Event),
+		Errors:   make(chan error, 1),
+		debounce: debounce,
+		dirs:     make(map[string]map[string]bool),
+		raw:      make(chan Event, 64),
+		done:     make(chan struct{}),
+	}
+}
+
+// Add a file or directory to watch. Directories are watched non-recursively.
+// Files don't need to exist yet, but their directory does.
+func (w *Watcher) Add(path string) error {
+	path = filepath.Clean(path)
+	dir, name := path, ""
+	if !DirExists(path) {
+		dir, name = filepath.Dir(path), filepath.Base(path)
+	}
+
+	w.Lock()
+	defer w.Unlock()
+	if w.closed {
+		return ErrWatcherClosed
+	}
+
+	names, ok := w.dirs[dir]
+	if !ok {
+		err := w.backend.add(dir)
+		if err != nil {
+			return err
+		}
+
+		names = make(map[string]bool)
+		w.dirs[dir] = names
+	}
+	names[name] = true
+	return nil
+}
+
+// Remove a file or directory from the watcher.
+func (w *Watcher) Remove(path string) error {
+	path = filepath.Clean(path)
+	w.Lock()
+	defer w.Unlock()
+	dir, name := path, ""
+	if _, ok := w.dirs[dir]; !ok {
+		dir, name = filepath.Dir(path), filepath.Base(path)
+	}
+
+	names, ok := w.dirs[dir]
+	if !ok || !names[name] {
+		return os.ErrNotExist
+	}
+
+	delete(names, name)
+	if len(names) > 0 {
+		return nil
+	}
+
+	delete(w.dirs, dir)
+	return w.backend.remove(dir)
+}
+
+// Close stops the watcher and closes the Events channel.
+func (w *Watcher) Close() error {
+	w.Lock()
+	if w.closed {
+		w.Unlock()
+		return nil
+	}
+
+	w.closed = true
+	w.Unlock()
+	close(w.done)
+	return w.backend.close()
+}
+
+// wanted returns true if a raw event is for a watched path.
+func (w *Watcher) wanted(name string) bool {
+	w.Lock()
+	defer w.Unlock()
+	names, ok := w.dirs[filepath.Dir(name)]
+	return ok && (names[""] || names[filepath.Base(name)])
+}
+
+// error passes on a backend error without blocking.
+func (w *Watcher) error(err error) {
+	select {
+	case w.Errors <- err:
+	default:
+	}
+}
+
+// run debounces raw events until the watcher is closed.
+func (w *Watcher) run() {
+	defer close(w.Events)
+	pending := make(map[string]Op)
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-w.done:
+			timer.Stop()
+			return
+		case e := <-w.raw:
+			if !w.wanted(e.Name) {
+				continue
+			}
+
+			pending[e.Name] |= e.Op
+			timer.Reset(w.debounce)
+		case <-timer.C:
+			names := make([]string, 0, len(pending))
+			for name := range pending {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				select {
+				case w.Events <- Event{Name: name, Op: pending[name]}:
+				case <-w.done:
+					return
+				}
+				delete(pending, name)
+			}
+		}
+	}
+}
+
+// Reload calls load every time the watcher reports a change and sends the new
+// value on the returned channel. It takes over the watcher's Events channel.
+// Failed loads go to the Errors channel, so a bad save doesn't replace a good
+// value. The returned channel is closed when the watcher is.
+func Reload[T any](w *Watcher, load func() (T, error)) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for range w.Events {
+			v, err := load()
+			if err != nil {
+				w.error(err)
+				continue
+			}
+
+			select {
+			case out <- v:
+			case <-w.done:
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// poller is the portable backend, comparing directory listings.
+type poller struct {
+	sync.Mutex
+	dirs map[string]map[string]fileState
+	raw  chan<- Event
+	errf func(error)
+	done chan struct{}
+}
+
+// fileState is what the poller compares between checks.
+type fileState struct {
+	mod  time.Time
+	size int64
+}
+
+func newPoller(interval time.Duration, raw chan<- Event, errf func(error)) *poller {
+	p := &poller{
+		dirs: make(map[string]map[string]fileState),
+		raw:  raw,
+		errf: errf,
+		done: make(chan struct{}),
+	}
+	go p.run(interval)
+	return p
+}
+
+func (p *poller) add(dir string) error {
+	st, err := scanDir(dir)
+	if err != nil {
+		return err
+	}
+
+	p.Lock()
+	p.dirs[dir] = st
+	p.Unlock()
+	return nil
+}
+
+func (p *poller) remove(dir string) error {
+	p.Lock()
+	delete(p.dirs, dir)
+	p.Unlock()
+	return nil
+}
+
+func (p *poller) close() error {
+	close(p.done)
+	return nil
+}
+
+func (p *poller) run(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-t.C:
+			p.check()
+		}
+	}
+}
+
+// check compares every directory with its last scan.
+func (p *poller) check() {
+	p.Lock()
+	dirs := make([]string, 0, len(p.dirs))
+	for dir := range p.dirs {
+		dirs = append(dirs, dir)
+	}
+	p.Unlock()
+
+	for _, dir := range dirs {
+		st, err := scanDir(dir)
+		if err != nil {
+			p.errf(err)
+			continue
+		}
+
+		p.Lock()
+		old, ok := p.dirs[dir]
+		if ok {
+			p.dirs[dir] = st
+		}
+		p.Unlock()
+		if !ok {
+			continue
+		}
+
+		for name, s := range st {
+			o, existed := old[name]
+			switch {
+			case !existed:
+				p.send(Event{Name: filepath.Join(dir, name), Op: Create})
+			case !o.mod.Equal(s.mod) || o.size != s.size:
+				p.send(Event{Name: filepath.Join(dir, name), Op: Write})
+			}
+		}
+		for name := range old {
+			if _, ok := st[name]; !ok {
+				p.send(Event{Name: filepath.Join(dir, name), Op: Remove})
+			}
+		}
+	}
+}
+
+func (p *poller) send(e Event) {
+	select {
+	case p.raw <- e:
+	case <-p.done:
+	}
+}
+
+// scanDir returns the state of all entries in a directory.
+func scanDir(dir string) (map[string]fileState, error) {
+	list, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	st := make(map[string]fileState, len(list))
+	for _, de := range list {
+		fi, err := de.Info()
+		if err != nil {
+			continue
+		}
+
+		st[de.Name()] = fileState{mod: fi.ModTime(), size: fi.Size()}
+	}
+	return st, nil
+}
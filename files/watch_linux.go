@@ -0,0 +1,145 @@
+//go:build linux
+// +build linux
+
+package files
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask is the set of changes watched in each directory.
+const inotifyMask = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ATTRIB
+
+// inotify is the Linux backend.
+type inotify struct {
+	sync.Mutex
+	f    *os.File
+	fd   int
+	wds  map[int]string
+	dirs map[string]int
+	raw  chan<- Event
+	errf func(error)
+	done chan struct{}
+}
+
+func newNativeBackend(raw chan<- Event, errf func(error)) (backend, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	in := &inotify{
+		// A non-blocking descriptor makes the file pollable, so Close() interrupts Read().
+		f:    os.NewFile(uintptr(fd), "inotify"),
+		fd:   fd,
+		wds:  make(map[int]string),
+		dirs: make(map[string]int),
+		raw:  raw,
+		errf: errf,
+		done: make(chan struct{}),
+	}
+	go in.run()
+	return in, nil
+}
+
+func (in *inotify) add(dir string) error {
+	in.Lock()
+	defer in.Unlock()
+	wd, err := syscall.InotifyAddWatch(in.fd, dir, inotifyMask)
+	if err != nil {
+		return &os.PathError{Op: "watch", Path: dir, Err: err}
+	}
+
+	in.wds[wd] = dir
+	in.dirs[dir] = wd
+	return nil
+}
+
+func (in *inotify) remove(dir string) error {
+	in.Lock()
+	defer in.Unlock()
+	wd, ok := in.dirs[dir]
+	if !ok {
+		return nil
+	}
+
+	delete(in.dirs, dir)
+	delete(in.wds, wd)
+	_, err := syscall.InotifyRmWatch(in.fd, uint32(wd))
+	return err
+}
+
+func (in *inotify) close() error {
+	close(in.done)
+	return in.f.Close()
+}
+
+// run reads and translates events until the file is closed.
+func (in *inotify) run() {
+	var buf [(syscall.SizeofInotifyEvent + syscall.NAME_MAX + 1) * 16]byte
+	for {
+		n, err := in.f.Read(buf[:])
+		if err != nil {
+			if errors.Is(err, os.ErrClosed) {
+				return
+			}
+
+			in.errf(err)
+			return
+		}
+
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			start := off + syscall.SizeofInotifyEvent
+			off = start + int(ev.Len)
+			if ev.Mask&syscall.IN_Q_OVERFLOW != 0 {
+				in.errf(errors.New("inotify queue overflow"))
+				continue
+			}
+
+			op := inotifyOp(ev.Mask)
+			if op == 0 {
+				continue
+			}
+
+			in.Lock()
+			dir, ok := in.wds[int(ev.Wd)]
+			in.Unlock()
+			if !ok {
+				continue
+			}
+
+			name := strings.TrimRight(string(buf[start:off]), "\x00")
+			select {
+			case in.raw <- Event{Name: filepath.Join(dir, name), Op: op}:
+			case <-in.done:
+				return
+			}
+		}
+	}
+}
+
+// inotifyOp translates an event mask.
+func inotifyOp(mask uint32) Op {
+	var op Op
+	if mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		op |= Create
+	}
+	if mask&(syscall.IN_MODIFY|syscall.IN_CLOSE_WRITE|syscall.IN_ATTRIB) != 0 {
+		op |= Write
+	}
+	if mask&syscall.IN_DELETE != 0 {
+		op |= Remove
+	}
+	if mask&syscall.IN_MOVED_FROM != 0 {
+		op |= Rename
+	}
+	return op
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package files
+
+import "errors"
+
+// newNativeBackend isn't available, so NewWatcher polls.
+func newNativeBackend(raw chan<- Event, errf func(error)) (backend, error) {
+	return nil, errors.New("no native watcher")
+}
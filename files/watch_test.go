@@ -0,0 +1,63 @@
+package files_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Urethramancer/signor/files"
+)
+
+func testWatcher(t *testing.T, w *files.Watcher) {
+	defer w.Close()
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "watched.txt")
+	err := w.Add(fn)
+	if err != nil {
+		t.Fatalf("Couldn't watch: %s", err.Error())
+	}
+
+	data := make(chan string)
+	reloaded := files.Reload(w, func() (string, error) {
+		b, err := os.ReadFile(fn)
+		return string(b), err
+	})
+	go func() {
+		for s := range reloaded {
+			data <- s
+		}
+	}()
+
+	// Unwatched files in the same directory are ignored.
+	err = os.WriteFile(filepath.Join(dir, "other.txt"), []byte("x"), 0600)
+	if err != nil {
+		t.Fatalf("Couldn't write: %s", err.Error())
+	}
+
+	for _, s := range []string{"first", "second"} {
+		err = files.WriteFile(fn, []byte(s))
+		if err != nil {
+			t.Fatalf("Couldn't write: %s", err.Error())
+		}
+
+		select {
+		case got := <-data:
+			if got != s {
+				t.Errorf("Expected %q, got %q", s, got)
+			}
+		case err := <-w.Errors:
+			t.Fatalf("Watcher error: %s", err.Error())
+		case <-time.After(5 * time.Second):
+			t.Fatalf("No reload after writing %q", s)
+		}
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	testWatcher(t, files.NewWatcher(50*time.Millisecond))
+}
+
+func TestPollingWatcher(t *testing.T) {
+	testWatcher(t, files.NewPollingWatcher(50*time.Millisecond, 20*time.Millisecond))
+}
@@ -0,0 +1,167 @@
+package files
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// WriteOptions control how WriteFile saves files.
+type WriteOptions struct {
+	// Mode is the permission bits for new files. Zero means 0600.
+	Mode os.FileMode
+	// KeepMode keeps the permissions of a file being replaced instead of using Mode.
+	KeepMode bool
+	// Backup keeps the previous version of a file being replaced as <name>.bak.
+	Backup bool
+}
+
+var (
+	writeMu   sync.RWMutex
+	writeOpts = WriteOptions{Mode: 0600, KeepMode: true}
+)
+
+// SetWriteOptions changes the options used by WriteFile, and so by SaveJSON and INI.Save.
+// The default is private permissions (0600) for new files, keeping existing permissions and no backups.
+func SetWriteOptions(o WriteOptions) {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	writeOpts = o
+}
+
+// GetWriteOptions returns the options used by WriteFile.
+func GetWriteOptions() WriteOptions {
+	writeMu.RLock()
+	defer writeMu.RUnlock()
+	return writeOpts
+}
+
+// WriteFile saves a file atomically with the options set by SetWriteOptions.
+func WriteFile(path string, data []byte) error {
+	return WriteFileOptions(path, data, GetWriteOptions())
+}
+
+// WriteFileOptions saves a file atomically and durably. The data is written to a
+// temporary file in the same directory, synced, renamed over the destination and
+// the directory synced, so readers see either the old or the new file, never a
+// partial one, and the new file survives a crash once this returns.
+// If path is a symlink, the file it points to is replaced.
+func WriteFileOptions(path string, data []byte, o WriteOptions) error {
+	target, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		path = target
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	mode := o.Mode
+	if mode == 0 {
+		mode = 0600
+	}
+
+	old, err := os.Stat(path)
+	exists := err == nil
+	if exists && o.KeepMode {
+		mode = old.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	name := tmp.Name()
+	ok := false
+	defer func() {
+		if !ok {
+			tmp.Close()
+			os.Remove(name)
+		}
+	}()
+
+	err = tmp.Chmod(mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		return err
+	}
+
+	err = tmp.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	if exists && o.Backup {
+		err = backup(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.Rename(name, path)
+	if err != nil {
+		return err
+	}
+
+	ok = true
+	return syncDir(dir)
+}
+
+// backup replaces <path>.bak with the current file. It's hard-linked where
+// possible, so the original stays in place until the rename replaces it.
+func backup(path string) error {
+	bak := path + ".bak"
+	err := os.Remove(bak)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if os.Link(path, bak) == nil {
+		return nil
+	}
+
+	return copyFile(path, bak)
+}
+
+// copyFile copies src to dst with the same permissions.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+	st, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, st.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	err = out.Sync()
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,98 @@
+package files_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Urethramancer/signor/files"
+)
+
+func TestWriteFileOptions(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "config.json")
+	o := files.WriteOptions{Mode: 0640, KeepMode: true, Backup: true}
+	err := files.WriteFileOptions(fn, []byte("one"), o)
+	if err != nil {
+		t.Fatalf("Couldn't write: %s", err.Error())
+	}
+
+	st, err := os.Stat(fn)
+	if err != nil {
+		t.Fatalf("Couldn't stat: %s", err.Error())
+	}
+	if st.Mode().Perm() != 0640 {
+		t.Errorf("Wrong mode: %v", st.Mode().Perm())
+	}
+
+	err = os.Chmod(fn, 0604)
+	if err != nil {
+		t.Fatalf("Couldn't chmod: %s", err.Error())
+	}
+
+	err = files.WriteFileOptions(fn, []byte("two"), o)
+	if err != nil {
+		t.Fatalf("Couldn't overwrite: %s", err.Error())
+	}
+
+	data, _ := os.ReadFile(fn)
+	bak, _ := os.ReadFile(fn + ".bak")
+	if string(data) != "two" || string(bak) != "one" {
+		t.Errorf("Expected two and backup one, got %q and %q", data, bak)
+	}
+
+	st, _ = os.Stat(fn)
+	if st.Mode().Perm() != 0604 {
+		t.Errorf("Mode not kept: %v", st.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(fn))
+	if len(entries) != 2 {
+		t.Errorf("Expected only the file and backup, got %d entries", len(entries))
+	}
+}
+
+func TestWriteFileZeroMode(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "new.txt")
+	err := files.WriteFileOptions(fn, []byte("data"), files.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Couldn't write: %s", err.Error())
+	}
+
+	st, err := os.Stat(fn)
+	if err != nil {
+		t.Fatalf("Couldn't stat: %s", err.Error())
+	}
+	if st.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %v", st.Mode().Perm())
+	}
+}
+
+func TestWriteFileSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.txt")
+	link := filepath.Join(dir, "link.txt")
+	err := os.WriteFile(target, []byte("old"), 0600)
+	if err != nil {
+		t.Fatalf("Couldn't write: %s", err.Error())
+	}
+
+	err = os.Symlink(target, link)
+	if err != nil {
+		t.Skipf("Can't create symlinks: %s", err.Error())
+	}
+
+	err = files.WriteFile(link, []byte("new"))
+	if err != nil {
+		t.Fatalf("Couldn't write through link: %s", err.Error())
+	}
+
+	fi, err := os.Lstat(link)
+	if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Link was replaced by a regular file")
+	}
+
+	data, _ := os.ReadFile(target)
+	if string(data) != "new" {
+		t.Errorf("Expected target to contain %q, got %q", "new", data)
+	}
+}
@@ -0,0 +1,61 @@
+package files
+
+import (
+	"bytes"
+	"io/fs"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadYAML and unmarshal structure.
+// Structures get their `default` tags before decoding and are checked against
+// their `validate` tags after, like LoadJSON().
+func LoadYAML(fn string, out interface{}) error {
+	return LoadYAMLFS(OS, fn, out)
+}
+
+// LoadYAMLFS loads YAML from a filesystem and unmarshals the structure,
+// with the same defaults and validation as LoadYAML().
+func LoadYAMLFS(fsys fs.FS, fn string, out interface{}) error {
+	f, err := fs.ReadFile(fsys, fn)
+	if err != nil {
+		return err
+	}
+
+	return loadStruct(out, func() error {
+		return yaml.Unmarshal(f, out)
+	})
+}
+
+// SaveYAML after marshalling with two-space indentation.
+func SaveYAML(path string, data interface{}) error {
+	return SaveYAMLFS(OS, path, data)
+}
+
+// SaveYAMLFS marshals with two-space indentation and saves to a filesystem.
+func SaveYAMLFS(fsys WriteFS, path string, data interface{}) error {
+	b, err := MarshalYAML(data)
+	if err != nil {
+		return err
+	}
+
+	return fsys.WriteFile(path, b)
+}
+
+// MarshalYAML returns the YAML encoding of data with two-space indentation.
+func MarshalYAML(data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err := enc.Encode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = enc.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
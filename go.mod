@@ -1,9 +1,11 @@
 module github.com/Urethramancer/signor
 
-go 1.17
+go 1.21
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/Urethramancer/cross v0.5.1
 	github.com/Urethramancer/daemon v0.3.0
 	github.com/mgutz/str v1.2.0
+	gopkg.in/yaml.v3 v3.0.1
 )
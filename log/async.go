@@ -0,0 +1,197 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/Urethramancer/signor/cfmt"
+)
+
+// Overflow policies for asynchronous logging.
+const (
+	// OverflowBlock makes the caller wait until there is room in the queue.
+	OverflowBlock = iota
+	// OverflowDropNewest discards the line being logged when the queue is full.
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest queued line to make room for the new one.
+	OverflowDropOldest
+)
+
+const (
+	// DefaultQueueSize is used when SetAsync is given a size below 1.
+	DefaultQueueSize = 1024
+)
+
+// asyncLine is a finished line waiting for the writer goroutine.
+type asyncLine struct {
+	w io.Writer
+	s string
+}
+
+// asyncQueue is a bounded queue drained by a single background writer.
+type asyncQueue struct {
+	sync.Mutex
+	// idle is signalled whenever pending drops to zero.
+	idle    *sync.Cond
+	queue   chan asyncLine
+	done    chan struct{}
+	policy  int
+	pending int
+	dropped uint64
+}
+
+func newAsyncQueue(size, policy int) *asyncQueue {
+	if size < 1 {
+		size = DefaultQueueSize
+	}
+
+	q := &asyncQueue{
+		queue:  make(chan asyncLine, size),
+		done:   make(chan struct{}),
+		policy: policy,
+	}
+	q.idle = sync.NewCond(&q.Mutex)
+	go q.run()
+	return q
+}
+
+// run writes queued lines until the queue is closed.
+func (q *asyncQueue) run() {
+	for line := range q.queue {
+		cfmt.InterruptFor(line.w, func() {
+			fmt.Fprint(line.w, line.s)
+		})
+		q.release(1)
+	}
+	close(q.done)
+}
+
+// release marks n lines as handled, whether written or dropped.
+func (q *asyncQueue) release(n int) {
+	q.Lock()
+	q.pending -= n
+	if q.pending == 0 {
+		q.idle.Broadcast()
+	}
+	q.Unlock()
+}
+
+// push a line according to the overflow policy.
+func (q *asyncQueue) push(w io.Writer, s string) {
+	line := asyncLine{w: w, s: s}
+	q.Lock()
+	q.pending++
+	q.Unlock()
+
+	switch q.policy {
+	case OverflowDropNewest:
+		select {
+		case q.queue <- line:
+		default:
+			q.drop()
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case q.queue <- line:
+				return
+			default:
+			}
+
+			select {
+			case <-q.queue:
+				q.drop()
+			default:
+			}
+		}
+	default:
+		q.queue <- line
+	}
+}
+
+// drop counts a discarded line.
+func (q *asyncQueue) drop() {
+	q.Lock()
+	q.dropped++
+	q.Unlock()
+	q.release(1)
+}
+
+// flush waits until every pushed line has been written or dropped.
+func (q *asyncQueue) flush() {
+	q.Lock()
+	for q.pending > 0 {
+		q.idle.Wait()
+	}
+	q.Unlock()
+}
+
+// close stops accepting lines and waits for the writer to drain the queue.
+func (q *asyncQueue) close() {
+	close(q.queue)
+	<-q.done
+}
+
+// SetAsync switches the logger to asynchronous output through a bounded queue
+// of the given size, drained by a background writer goroutine.
+// The policy decides what happens when the queue is full; see OverflowBlock,
+// OverflowDropNewest and OverflowDropOldest.
+// Calling it on an already asynchronous logger drains the old queue first.
+func (l *Logger) SetAsync(size, policy int) {
+	l = l.base()
+	l.asyncMu.Lock()
+	defer l.asyncMu.Unlock()
+	if l.async != nil {
+		l.async.close()
+	}
+	l.async = newAsyncQueue(size, policy)
+}
+
+// IsAsync returns true if the logger is writing asynchronously.
+func (l *Logger) IsAsync() bool {
+	l = l.base()
+	l.asyncMu.RLock()
+	defer l.asyncMu.RUnlock()
+	return l.async != nil
+}
+
+// Dropped returns the number of lines discarded by the overflow policy.
+func (l *Logger) Dropped() uint64 {
+	l = l.base()
+	l.asyncMu.RLock()
+	defer l.asyncMu.RUnlock()
+	if l.async == nil {
+		return 0
+	}
+
+	l.async.Lock()
+	defer l.async.Unlock()
+	return l.async.dropped
+}
+
+// Flush logs summaries of any messages suppressed by sampling, and blocks until
+// all queued lines have been written.
+func (l *Logger) Flush() {
+	l = l.base()
+	l.flushSamples()
+	l.asyncMu.RLock()
+	defer l.asyncMu.RUnlock()
+	if l.async != nil {
+		l.async.flush()
+	}
+}
+
+// Close drains the queue, stops the background writer and returns the logger
+// to synchronous output. Call it on shutdown to avoid losing messages.
+func (l *Logger) Close() {
+	l = l.base()
+	l.asyncMu.Lock()
+	defer l.asyncMu.Unlock()
+	if l.async == nil {
+		return
+	}
+
+	l.async.close()
+	l.async = nil
+}
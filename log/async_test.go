@@ -0,0 +1,54 @@
+package log_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Urethramancer/signor/log"
+)
+
+func TestAsync(t *testing.T) {
+	dir := t.TempDir()
+	msgs := filepath.Join(dir, "msg.log")
+	errs := filepath.Join(dir, "err.log")
+	l := log.NewLogger()
+	l.SetLogOut(log.O_FILE, []string{msgs, errs}, nil)
+	defer l.CloseFiles()
+
+	l.SetAsync(4, log.OverflowBlock)
+	for i := 0; i < 100; i++ {
+		l.Msg("line %d", i)
+	}
+	l.Err("oops")
+	l.Flush()
+
+	data, err := os.ReadFile(msgs)
+	if err != nil {
+		t.Fatalf("Couldn't read %s: %s", msgs, err.Error())
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 100 {
+		t.Errorf("Expected 100 lines, got %d", len(lines))
+	}
+
+	if lines[99] != "line 99" {
+		t.Errorf("Lines out of order: last is %q", lines[99])
+	}
+
+	l.Close()
+	if l.IsAsync() {
+		t.Errorf("Logger still asynchronous after Close()")
+	}
+
+	data, err = os.ReadFile(errs)
+	if err != nil {
+		t.Fatalf("Couldn't read %s: %s", errs, err.Error())
+	}
+
+	if string(data) != "oops\n" {
+		t.Errorf("Unexpected error output %q", string(data))
+	}
+}
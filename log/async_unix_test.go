@@ -0,0 +1,77 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package log_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/Urethramancer/signor/log"
+)
+
+func TestAsyncDrop(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  int
+		survive string
+	}{
+		{"newest", log.OverflowDropNewest, "line 1"},
+		{"oldest", log.OverflowDropOldest, "line 10"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// The writer blocks on a FIFO nobody reads yet, so the queue fills up.
+			dir := t.TempDir()
+			fifo := filepath.Join(dir, "msg.fifo")
+			err := syscall.Mkfifo(fifo, 0600)
+			if err != nil {
+				t.Skipf("Couldn't create a FIFO: %s", err.Error())
+			}
+
+			r, err := os.OpenFile(fifo, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+			if err != nil {
+				t.Fatalf("Couldn't open %s: %s", fifo, err.Error())
+			}
+			defer r.Close()
+
+			l := log.NewLogger()
+			l.SetLogOut(log.O_FILE, []string{fifo, filepath.Join(dir, "err.log")}, nil)
+			l.SetAsync(1, tc.policy)
+
+			// Far bigger than a pipe buffer. Reading a byte of it shows the writer
+			// has taken it off the queue, and it's stuck until the rest is read.
+			big := strings.Repeat("x", 1<<20)
+			l.Msg("%s", big)
+			_, err = r.Read(make([]byte, 1))
+			if err != nil {
+				t.Fatalf("Couldn't read %s: %s", fifo, err.Error())
+			}
+
+			for i := 1; i <= 10; i++ {
+				l.Msg("line %d", i)
+			}
+			if l.Dropped() != 9 {
+				t.Errorf("Expected 9 dropped lines, got %d", l.Dropped())
+			}
+
+			done := make(chan []byte)
+			go func() {
+				data, _ := io.ReadAll(r)
+				done <- data
+			}()
+			l.Close()
+			l.CloseFiles()
+			data := <-done
+
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			if len(lines) != 2 || lines[1] != tc.survive {
+				t.Errorf("Expected %q to survive, got %q", tc.survive, lines[1:])
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package log
+
+import (
+	"context"
+	"io"
+	oldlog "log"
+	"log/slog"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Levels used when converting to and from log/slog.
+const (
+	// LevelInfo is level 0, written to the message output.
+	LevelInfo uint = iota
+	// LevelWarn is level 1, written to the error output like Err().
+	LevelWarn
+	// LevelError is level 2.
+	LevelError
+)
+
+// SlogLevel converts a signor level to a log/slog level.
+func SlogLevel(level uint) slog.Level {
+	switch level {
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	}
+
+	return slog.LevelError
+}
+
+// LevelFromSlog converts a log/slog level to a signor level.
+// Debug and info become 0, warnings 1 and errors 2.
+func LevelFromSlog(level slog.Level) uint {
+	switch {
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarn
+	}
+
+	return LevelError
+}
+
+// stdWriter turns each write from a standard library logger into a message.
+type stdWriter struct {
+	// l is the logger to write to, or nil for whatever Default is at the time.
+	l     *Logger
+	level uint
+}
+
+// Write a line.
+func (w stdWriter) Write(p []byte) (int, error) {
+	l := w.l
+	if l == nil {
+		l = Default
+	}
+
+	msg := strings.TrimSuffix(string(p), "\n")
+	if l.sample(w.level, msg) {
+		l.line(w.level, false, msg)
+	}
+	return len(p), nil
+}
+
+// Writer returns an io.Writer which logs each write as a message of the level.
+// Level 0 goes to the message output, anything else to the error output.
+func (l *Logger) Writer(level uint) io.Writer {
+	return stdWriter{l: l, level: level}
+}
+
+// RedirectStdLog sends the standard library logger, and log/slog's default handler
+// unless slog.SetDefault() has been called, to whatever Default is at the time,
+// as messages of the level. Its flags are cleared, since Default adds its own details.
+func RedirectStdLog(level uint) {
+	oldlog.SetFlags(0)
+	oldlog.SetOutput(stdWriter{level: level})
+}
+
+// StdLogger returns a standard library logger writing through this logger at the level.
+// Use it for http.Server.ErrorLog and libraries which want a *log.Logger.
+func (l *Logger) StdLogger(level uint) *oldlog.Logger {
+	return oldlog.New(l.Writer(level), "", 0)
+}
+
+// SlogHandler is a log/slog handler writing events to a signor Logger.
+type SlogHandler struct {
+	l      *Logger
+	group  string
+	source bool
+	level  uint
+}
+
+// NewSlogHandler returns a log/slog handler which logs through l.
+// If source is true, the caller's file and line are put in Event.Source.
+//
+//	slog.SetDefault(slog.New(log.NewSlogHandler(log.Default, false)))
+func NewSlogHandler(l *Logger, source bool) *SlogHandler {
+	return &SlogHandler{l: l, source: source}
+}
+
+// WithLevel returns a handler which only logs records at or above the level,
+// after converting them with LevelFromSlog(). The default of LevelInfo logs everything.
+func (h *SlogHandler) WithLevel(level uint) *SlogHandler {
+	return &SlogHandler{l: h.l, group: h.group, source: h.source, level: level}
+}
+
+// Enabled returns true if the level converts to one at or above the handler's.
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return LevelFromSlog(level) >= h.level
+}
+
+// Handle logs the record as an event.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	e := &Event{
+		Level:   LevelFromSlog(r.Level),
+		Time:    r.Time,
+		Message: r.Message,
+	}
+	if r.NumAttrs() > 0 {
+		e.Fields = make(map[string]interface{}, r.NumAttrs())
+		r.Attrs(func(a slog.Attr) bool {
+			addAttr(e.Fields, h.group, a)
+			return true
+		})
+	}
+	if h.source && r.PC != 0 {
+		frames := runtime.CallersFrames([]uintptr{r.PC})
+		f, _ := frames.Next()
+		e.Source = f.File + ":" + strconv.Itoa(f.Line)
+	}
+	h.l.Log(e)
+	return nil
+}
+
+// WithAttrs returns a handler whose logger has the attributes as fields.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	m := make(map[string]interface{}, len(attrs))
+	for _, a := range attrs {
+		addAttr(m, h.group, a)
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kv := make([]interface{}, 0, len(m)*2)
+	for _, k := range keys {
+		kv = append(kv, k, m[k])
+	}
+
+	return &SlogHandler{l: h.l.With(kv...), group: h.group, source: h.source, level: h.level}
+}
+
+// WithGroup returns a handler which prefixes following attribute keys with the group name.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &SlogHandler{l: h.l, group: h.group + name + ".", source: h.source, level: h.level}
+}
+
+// addAttr flattens an attribute into fields, joining group names with dots.
+func addAttr(m map[string]interface{}, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		p := prefix
+		if a.Key != "" {
+			p = prefix + a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			addAttr(m, p, ga)
+		}
+		return
+	}
+
+	if a.Key == "" {
+		return
+	}
+
+	m[prefix+a.Key] = v.Any()
+}
+
+// SlogSink forwards events to an existing log/slog handler.
+type SlogSink struct {
+	h slog.Handler
+}
+
+// NewSlogSink returns a sink for AddSink() which passes events on to h.
+func NewSlogSink(h slog.Handler) *SlogSink {
+	return &SlogSink{h: h}
+}
+
+// Log converts the event to a record and hands it to the slog handler.
+func (s *SlogSink) Log(e *Event) {
+	level := SlogLevel(e.Level)
+	ctx := context.Background()
+	if !s.h.Enabled(ctx, level) {
+		return
+	}
+
+	t := e.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	r := slog.NewRecord(t, level, e.Message, 0)
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		r.AddAttrs(slog.Any(k, e.Fields[k]))
+	}
+	if e.Source != "" {
+		r.AddAttrs(slog.String("source", e.Source))
+	}
+	// Sinks have nowhere to report errors, and logging them could loop.
+	_ = s.h.Handle(ctx, r)
+}
@@ -0,0 +1,74 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	stdlog "log"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Urethramancer/signor/log"
+	"github.com/Urethramancer/signor/log/logtest"
+)
+
+func TestStdLogger(t *testing.T) {
+	logs := logtest.New()
+	std := logs.Logger.With("server", "web:80").StdLogger(log.LevelWarn)
+	std.Printf("http: TLS handshake error from %s", "127.0.0.1")
+	logs.AssertLogged(t, log.LevelWarn, "TLS handshake error from 127.0.0.1")
+	logs.AssertField(t, log.LevelWarn, "server", "web:80")
+}
+
+func TestSlogHandler(t *testing.T) {
+	logs := logtest.New()
+	sl := slog.New(log.NewSlogHandler(logs.Logger, true))
+	sl.With("site", "example.com").WithGroup("req").Error("failed", "status", 500)
+	logs.AssertLogged(t, log.LevelError, "failed")
+	logs.AssertField(t, log.LevelError, "site", "example.com")
+	logs.AssertField(t, log.LevelError, "req.status", int64(500))
+
+	ev := logs.Events()
+	if !strings.Contains(ev[0].Source, "bridge_test.go") {
+		t.Errorf("Unexpected source %q", ev[0].Source)
+	}
+}
+
+func TestSlogSink(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewLogger()
+	l.SetLogOut(0, nil, nil)
+	l.AddSink(log.NewSlogSink(slog.NewTextHandler(&buf, nil)))
+	l.With("site", "example.com").Err("broken %d", 1)
+
+	out := buf.String()
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, `msg="broken 1"`) || !strings.Contains(out, "site=example.com") {
+		t.Errorf("Unexpected slog output %q", out)
+	}
+}
+
+func TestSlogHandlerLevel(t *testing.T) {
+	logs := logtest.New()
+	sl := slog.New(log.NewSlogHandler(logs.Logger, false).WithLevel(log.LevelWarn))
+	if sl.Enabled(context.Background(), slog.LevelInfo) || !sl.Enabled(context.Background(), slog.LevelWarn) {
+		t.Errorf("Enabled() doesn't follow the handler level")
+	}
+
+	sl.With("site", "example.com").Info("ignored")
+	sl.WithGroup("req").Error("failed")
+	logs.AssertNotLogged(t, log.LevelInfo, "ignored")
+	logs.AssertLogged(t, log.LevelError, "failed")
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	logs := logtest.SwapDefault(t)
+	log.RedirectStdLog(log.LevelWarn)
+	defer func() {
+		stdlog.SetOutput(os.Stderr)
+		stdlog.SetFlags(stdlog.LstdFlags)
+	}()
+
+	stdlog.Printf("from %s", "stdlib")
+	logs.AssertLogged(t, log.LevelWarn, "from stdlib")
+}
@@ -0,0 +1,168 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Enrichment options for SetEnrich(). Combine them with |.
+const (
+	// EnrichCaller sets Event.Source to the file and line the message was logged from.
+	EnrichCaller = 1 << iota
+	// EnrichHost sets Event.Hostname.
+	EnrichHost
+	// EnrichPID sets Event.PID.
+	EnrichPID
+	// EnrichName sets Event.Name to the logger's name, which defaults to the program name.
+	EnrichName
+	// EnrichStack sets Event.Stack for events at or above the stack level.
+	EnrichStack
+	// EnrichAll turns on everything.
+	EnrichAll = EnrichCaller | EnrichHost | EnrichPID | EnrichName | EnrichStack
+)
+
+// enricher settings for a logger.
+type enricher struct {
+	sync.RWMutex
+	flags      int
+	name       string
+	stackLevel uint
+}
+
+var (
+	hostOnce sync.Once
+	hostname string
+	pid      = os.Getpid()
+)
+
+// Hostname returns the cached name of the host.
+func Hostname() string {
+	hostOnce.Do(func() {
+		hostname, _ = os.Hostname()
+	})
+	return hostname
+}
+
+// SetEnrich chooses which Event fields the logger fills in automatically.
+// Only fields the caller left empty are set. Events are enriched in Log(), and
+// before messages from Msg(), Err() and friends are passed to sinks.
+func (l *Logger) SetEnrich(flags int) {
+	l = l.base()
+	l.enrich.Lock()
+	defer l.enrich.Unlock()
+	l.enrich.flags = flags
+	if l.enrich.name == "" {
+		l.enrich.name = filepath.Base(os.Args[0])
+	}
+	if l.enrich.stackLevel == 0 {
+		l.enrich.stackLevel = LevelError
+	}
+}
+
+// SetName sets the application name used by EnrichName.
+func (l *Logger) SetName(name string) {
+	en := &l.base().enrich
+	en.Lock()
+	defer en.Unlock()
+	en.name = name
+}
+
+// SetStackLevel sets the lowest level EnrichStack captures stack traces for.
+// The default is LevelError.
+func (l *Logger) SetStackLevel(level uint) {
+	en := &l.base().enrich
+	en.Lock()
+	defer en.Unlock()
+	en.stackLevel = level
+}
+
+// enrichEvent fills in the enabled fields.
+func (l *Logger) enrichEvent(e *Event) {
+	en := l.enrichSettings()
+	if en.flags == 0 {
+		return
+	}
+
+	if en.flags&EnrichHost != 0 && e.Hostname == "" {
+		e.Hostname = Hostname()
+	}
+	if en.flags&EnrichPID != 0 && e.PID == 0 {
+		e.PID = pid
+	}
+	if en.flags&EnrichName != 0 && e.Name == "" {
+		e.Name = en.name
+	}
+
+	wantCaller := en.flags&EnrichCaller != 0 && e.Source == ""
+	wantStack := en.flags&EnrichStack != 0 && e.Stack == "" && e.Level >= en.stackLevel
+	if !wantCaller && !wantStack {
+		return
+	}
+
+	frames := callerFrames()
+	if wantCaller {
+		f, _ := frames.Next()
+		if f.PC != 0 {
+			e.Source = f.File + ":" + strconv.Itoa(f.Line)
+		}
+		if wantStack {
+			frames = callerFrames()
+		}
+	}
+	if wantStack {
+		var b strings.Builder
+		for {
+			f, more := frames.Next()
+			if f.PC == 0 {
+				break
+			}
+
+			b.WriteString(f.Function)
+			b.WriteString("\n\t")
+			b.WriteString(f.File)
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(f.Line))
+			b.WriteByte('\n')
+			if !more {
+				break
+			}
+		}
+		e.Stack = b.String()
+	}
+}
+
+// enrichSettings returns a copy of the enrichment settings.
+func (l *Logger) enrichSettings() enricher {
+	en := &l.base().enrich
+	en.RLock()
+	defer en.RUnlock()
+	return enricher{flags: en.flags, name: en.name, stackLevel: en.stackLevel}
+}
+
+// callerFrames returns the stack starting at the first frame outside the logging
+// packages, so it works the same through Msg(), Log(), StdLogger() and log/slog.
+func callerFrames() *runtime.Frames {
+	pc := make([]uintptr, 64)
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	skip := 0
+	for {
+		f, more := frames.Next()
+		if !isLogFrame(f.Function) || !more {
+			break
+		}
+		skip++
+	}
+	return runtime.CallersFrames(pc[skip:n])
+}
+
+// isLogFrame returns true for functions in this package or the standard library loggers.
+func isLogFrame(fn string) bool {
+	return strings.HasPrefix(fn, "github.com/Urethramancer/signor/log.") ||
+		strings.HasPrefix(fn, "log.") ||
+		strings.HasPrefix(fn, "log/slog.")
+}
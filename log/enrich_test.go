@@ -0,0 +1,62 @@
+package log_test
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Urethramancer/signor/log"
+	"github.com/Urethramancer/signor/log/logtest"
+)
+
+func TestEnrich(t *testing.T) {
+	logs := logtest.New()
+	l := logs.Logger
+	l.SetEnrich(log.EnrichAll)
+	l.SetName("testapp")
+
+	l.Err("warning")
+	l.Log(&log.Event{Level: log.LevelError, Message: "failure"})
+	slog.New(log.NewSlogHandler(l, false)).Info("via slog")
+
+	ev := logs.Events()
+	if len(ev) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(ev))
+	}
+
+	for _, e := range ev {
+		if !strings.Contains(e.Source, "enrich_test.go") {
+			t.Errorf("%q: unexpected source %q", e.Message, e.Source)
+		}
+		if e.PID != os.Getpid() || e.Hostname != log.Hostname() || e.Name != "testapp" {
+			t.Errorf("%q: missing enrichment: %d %q %q", e.Message, e.PID, e.Hostname, e.Name)
+		}
+	}
+
+	if ev[0].Stack != "" {
+		t.Errorf("Stack captured below the stack level")
+	}
+
+	if !strings.Contains(ev[1].Stack, "TestEnrich") {
+		t.Errorf("Unexpected stack:\n%s", ev[1].Stack)
+	}
+}
+
+func TestEnrichConcurrent(t *testing.T) {
+	logs := logtest.New()
+	l := logs.Logger
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			l.SetEnrich(log.EnrichName)
+			l.SetName("app")
+			l.SetStackLevel(log.LevelError)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		l.Log(&log.Event{Message: "event"})
+	}
+	<-done
+}
@@ -1,10 +1,8 @@
 package log
 
 import (
-	"fmt"
 	"strings"
 	"time"
-	"unicode"
 )
 
 // Event line in a log file. Status, warnings, failures etc.
@@ -27,77 +25,46 @@ type Event struct {
 	Message string `json:"message,omitempty"`
 	// Extra strings for whatever.
 	Extra []string `json:"extra,omitempty"`
+	// Stack trace, if captured.
+	Stack string `json:"stack,omitempty"`
+	// Fields are structured key-value pairs, available to formats as %{key}.
+	Fields map[string]interface{} `json:"fields,omitempty"`
 }
 
-// Log format keywords
-const (
-	fmtLevel   = "%level"
-	fmtPID     = "%pid"
-	fmtTime    = "%time"
-	fmtName    = "%name"
-	fmtHost    = "%host"
-	fmtSource  = "%src"
-	fmtMessage = "%msg"
-	fmtExtra   = "%extra"
-)
-
 // Fmt creates a log event string from the provided format.
+// Up to 64 formats are cached after compiling, so use Compile() and Format() for
+// formats built at runtime. See Template for the keywords.
 // Use Event.String() to look it up again without reparsing.
 func (e *Event) Fmt(f string) string {
-	e.Reset()
-	for len(f) > 0 {
-		c := f[0]
-		if c == '%' {
-			var key string
-			key, f = e.parseKeyword(f)
-			switch key {
-			case fmtLevel:
-				e.WriteString(fmt.Sprintf("%d", e.Level))
-			case fmtPID:
-				e.WriteString(fmt.Sprintf("%d", e.PID))
-			case fmtTime:
-				e.WriteString(NowString())
-			case fmtName:
-				e.WriteString(e.Name)
-			case fmtHost:
-				e.WriteString(e.Hostname)
-			case fmtSource:
-				e.WriteString(e.Source)
-			case fmtMessage:
-				e.WriteString(e.Message)
-			case fmtExtra:
-				s := strings.Join(e.Extra, ",")
-				e.WriteString(s)
-			default:
-				// This skips fmt keywords, which could be useful.
-				e.WriteString(key)
-			}
-		} else {
-			e.WriteByte(f[0])
-			f = f[1:]
-		}
-	}
-	e.WriteString("\n")
-	return e.String()
+	return cachedTemplate(f).execute(e, nil)
 }
 
-// parseKeyword returns the parsed keyword and the rest of the input string.
-func (e *Event) parseKeyword(f string) (string, string) {
-	var b strings.Builder
-	if len(f) == 0 {
-		return "", f
-	}
+// Format creates a log event string from a compiled template.
+func (e *Event) Format(t *Template) string {
+	return t.execute(e, nil)
+}
 
-	b.WriteByte(f[0])
-	in := f[1:]
-	loop := true
-	for len(in) > 0 && loop {
-		if !unicode.IsLetter(rune(in[0])) {
-			loop = false
-		} else {
-			b.WriteByte(in[0])
-			in = in[1:]
+// Clone returns a copy of the event without its formatted text, safe to keep.
+func (e *Event) Clone() Event {
+	c := Event{
+		Level:    e.Level,
+		PID:      e.PID,
+		Time:     e.Time,
+		Name:     e.Name,
+		Hostname: e.Hostname,
+		Source:   e.Source,
+		Message:  e.Message,
+		Stack:    e.Stack,
+	}
+	if e.Extra != nil {
+		c.Extra = make([]string, len(e.Extra))
+		copy(c.Extra, e.Extra)
+	}
+	if e.Fields != nil {
+		c.Fields = make(map[string]interface{}, len(e.Fields))
+		for k, v := range e.Fields {
+			c.Fields[k] = v
 		}
 	}
-	return b.String(), in
+	return c
 }
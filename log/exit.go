@@ -0,0 +1,92 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ExitFunc ends the program with a status code. The default is os.Exit.
+type ExitFunc func(code int)
+
+// ExitCode is the value ExitPanic panics with.
+type ExitCode int
+
+// Error makes ExitCode usable as an error, for test output.
+func (c ExitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(c))
+}
+
+// ExitPanic panics with an ExitCode instead of exiting, so tests can recover
+// from Warn(), Fail() and Exit(). Use it with CatchExit().
+func ExitPanic(code int) {
+	panic(ExitCode(code))
+}
+
+// exiter holds the exit strategy and shutdown hooks.
+type exiter struct {
+	sync.Mutex
+	exit  ExitFunc
+	hooks []func()
+}
+
+// SetExitFunc changes how Exit(), Warn() and Fail() end the program.
+// A nil function restores os.Exit.
+func (l *Logger) SetExitFunc(f ExitFunc) {
+	l = l.base()
+	l.exiter.Lock()
+	defer l.exiter.Unlock()
+	l.exiter.exit = f
+}
+
+// OnExit adds a shutdown hook to run before the program exits through the logger.
+// Hooks run in reverse order of addition, like deferred calls.
+func (l *Logger) OnExit(f func()) {
+	l = l.base()
+	l.exiter.Lock()
+	defer l.exiter.Unlock()
+	l.exiter.hooks = append(l.exiter.hooks, f)
+}
+
+// Exit runs the shutdown hooks, drains any asynchronous output and ends the
+// program with the code, using the configured exit function.
+// Hooks only run once, even if Exit is called again. The logger stays usable,
+// and asynchronous if it was, in case the exit function returns.
+func (l *Logger) Exit(code int) {
+	l = l.base()
+	l.exiter.Lock()
+	hooks := l.exiter.hooks
+	l.exiter.hooks = nil
+	exit := l.exiter.exit
+	l.exiter.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+	l.Flush()
+	if exit == nil {
+		exit = os.Exit
+	}
+	exit(code)
+}
+
+// CatchExit runs f and recovers an ExitCode panic from ExitPanic.
+// It returns the code and true if f tried to exit. Other panics are passed on.
+func CatchExit(f func()) (code int, exited bool) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		c, ok := r.(ExitCode)
+		if !ok {
+			panic(r)
+		}
+
+		code = int(c)
+		exited = true
+	}()
+	f()
+	return 0, false
+}
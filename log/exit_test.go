@@ -0,0 +1,70 @@
+package log_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Urethramancer/signor/log"
+)
+
+func TestExit(t *testing.T) {
+	l := log.NewLogger()
+	l.SetLogOut(0, nil, nil)
+	l.SetExitFunc(log.ExitPanic)
+	var order []int
+	l.OnExit(func() { order = append(order, 1) })
+	l.OnExit(func() { order = append(order, 2) })
+
+	code, exited := log.CatchExit(func() {
+		l.Warn(nil, false)
+	})
+	if exited {
+		t.Errorf("Warn(nil) shouldn't exit")
+	}
+
+	code, exited = log.CatchExit(func() {
+		defer l.Fail(errors.New("broken"), false)
+	})
+	if !exited || code != 2 {
+		t.Errorf("Expected exit code 2, got %d (exited=%t)", code, exited)
+	}
+
+	if len(order) != 2 || order[0] != 2 || order[1] != 1 {
+		t.Errorf("Hooks ran in the wrong order: %v", order)
+	}
+
+	code, _ = log.CatchExit(func() {
+		l.With("child", true).Warn(errors.New("minor"), true)
+	})
+	if code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+
+	if len(order) != 2 {
+		t.Errorf("Hooks ran more than once: %v", order)
+	}
+}
+
+func TestExitKeepsAsync(t *testing.T) {
+	l := log.NewLogger()
+	l.SetLogOut(0, nil, nil)
+	l.SetExitFunc(log.ExitPanic)
+	l.SetAsync(16, log.OverflowBlock)
+	defer l.Close()
+
+	log.CatchExit(func() {
+		l.Exit(1)
+	})
+	if !l.IsAsync() {
+		t.Errorf("Exit() turned off asynchronous logging")
+	}
+
+	var codes []int
+	l.SetExitFunc(func(code int) {
+		codes = append(codes, code)
+	})
+	l.Fail(errors.New("broken"), false)
+	if len(codes) != 1 || codes[0] != 2 {
+		t.Errorf("Expected one exit with code 2, got %v", codes)
+	}
+}
@@ -0,0 +1,126 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Field is a key-value pair attached to a logger with With().
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// With returns a child logger which prefixes every message with the given fields,
+// and adds them to every logged Event. Arguments are alternating keys and values:
+//
+//	l.With("site", "example.com", "server", "web:443")
+//
+// Fields with a key already on the logger replace the old value.
+// The child writes through its parent's outputs, so SetLogOut(), SetAsync(),
+// Flush() and friends on either affect both. Formats and level colours are
+// copied, and can be changed on the child independently.
+func (l *Logger) With(kv ...interface{}) *Logger {
+	child := &Logger{
+		msgF:   l.msgF,
+		errF:   l.errF,
+		msgT:   l.msgT,
+		errT:   l.errT,
+		root:   l.base(),
+		fields: make([]Field, len(l.fields)),
+	}
+	copy(child.fields, l.fields)
+	if l.colours != nil {
+		child.colours = make(map[uint]string, len(l.colours))
+		for k, v := range l.colours {
+			child.colours[k] = v
+		}
+	}
+	for i := 0; i < len(kv); i += 2 {
+		key := fmt.Sprint(kv[i])
+		var value interface{}
+		if i+1 < len(kv) {
+			value = kv[i+1]
+		}
+		child.setField(key, value)
+	}
+
+	var b strings.Builder
+	for _, f := range child.fields {
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		b.WriteString(fmt.Sprint(f.Value))
+		b.WriteByte(' ')
+	}
+	child.prefix = b.String()
+	return child
+}
+
+// Fields returns a copy of the fields added with With().
+func (l *Logger) Fields() []Field {
+	list := make([]Field, len(l.fields))
+	copy(list, l.fields)
+	return list
+}
+
+// setField replaces or appends a field.
+func (l *Logger) setField(key string, value interface{}) {
+	for i := range l.fields {
+		if l.fields[i].Key == key {
+			l.fields[i].Value = value
+			return
+		}
+	}
+
+	l.fields = append(l.fields, Field{Key: key, Value: value})
+}
+
+// mergeFields adds the logger's fields to an event, keeping any it already has.
+// The event gets a new map, so the caller's is left alone.
+func (l *Logger) mergeFields(e *Event) {
+	if len(l.fields) == 0 {
+		return
+	}
+
+	fields := make(map[string]interface{}, len(l.fields)+len(e.Fields))
+	for _, f := range l.fields {
+		fields[f.Key] = f.Value
+	}
+	for k, v := range e.Fields {
+		fields[k] = v
+	}
+	e.Fields = fields
+}
+
+// base returns the logger which owns the outputs.
+func (l *Logger) base() *Logger {
+	if l.root != nil {
+		return l.root
+	}
+
+	return l
+}
+
+// contextKey is the type of the context key for loggers, to avoid collisions.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the logger.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, or Default if there is none.
+func FromContext(ctx context.Context) *Logger {
+	l, ok := ctx.Value(contextKey{}).(*Logger)
+	if !ok || l == nil {
+		return Default
+	}
+
+	return l
+}
+
+// ContextWith returns a copy of ctx carrying a child of its logger with more fields.
+func ContextWith(ctx context.Context, kv ...interface{}) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(kv...))
+}
@@ -0,0 +1,80 @@
+package log_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Urethramancer/signor/cfmt"
+	"github.com/Urethramancer/signor/log"
+)
+
+func TestWith(t *testing.T) {
+	dir := t.TempDir()
+	msgs := filepath.Join(dir, "msg.log")
+	l := log.NewLogger()
+	l.SetLogOut(log.O_FILE, []string{msgs, filepath.Join(dir, "err.log")}, nil)
+	defer l.CloseFiles()
+
+	srv := l.With("server", "main")
+	web := srv.With("server", "web:443", "site", "example.com")
+	web.Msg("hello")
+	srv.Msg("world")
+
+	data, err := os.ReadFile(msgs)
+	if err != nil {
+		t.Fatalf("Couldn't read %s: %s", msgs, err.Error())
+	}
+
+	want := "server=web:443 site=example.com hello\nserver=main world\n"
+	if string(data) != want {
+		t.Errorf("Got %q, expected %q", string(data), want)
+	}
+
+	ctx := log.NewContext(context.Background(), web)
+	if log.FromContext(ctx) != web {
+		t.Errorf("FromContext() didn't return the stored logger")
+	}
+
+	if log.FromContext(context.Background()) != log.Default {
+		t.Errorf("FromContext() without a logger should return Default")
+	}
+
+	ctx = log.ContextWith(ctx, "request_id", 7)
+	f := log.FromContext(ctx).Fields()
+	if len(f) != 3 || f[2].Key != "request_id" {
+		t.Errorf("Unexpected fields %v", f)
+	}
+}
+
+func TestWithCopies(t *testing.T) {
+	dir := t.TempDir()
+	msgs := filepath.Join(dir, "msg.log")
+	l := log.NewLogger()
+	l.SetLogOut(log.O_FILE, []string{msgs, filepath.Join(dir, "err.log")}, nil)
+	defer l.CloseFiles()
+	l.SetFmt("%colour%msg")
+	l.SetLevelColour(0, "red")
+
+	child := l.With("server", "web")
+	child.SetLevelColour(0, "")
+	child.Log(&log.Event{Message: "child"})
+	l.Log(&log.Event{Message: "parent"})
+
+	data, err := os.ReadFile(msgs)
+	if err != nil {
+		t.Fatalf("Couldn't read %s: %s", msgs, err.Error())
+	}
+
+	want := "child\n" + cfmt.Red + "parent\n"
+	if string(data) != want {
+		t.Errorf("Level colours aren't independent: got %q, expected %q", string(data), want)
+	}
+
+	fields := map[string]interface{}{"site": "example.com"}
+	child.Log(&log.Event{Message: "fields", Fields: fields})
+	if len(fields) != 1 {
+		t.Errorf("Log() changed the caller's fields: %v", fields)
+	}
+}
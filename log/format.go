@@ -0,0 +1,299 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Urethramancer/signor/cfmt"
+)
+
+// Template is a compiled log format. Compile it once with Compile() and reuse it
+// for every event, instead of re-scanning the format string each time.
+//
+// Keywords:
+//
+//	%level, %pid, %name, %host, %src, %msg, %extra - the Event fields
+//	%time - the event time in the default detailed layout
+//	%time{2006-01-02 15:04:05} - the event time in a custom Go time layout
+//	%{request_id} - a structured field from Event.Fields
+//	%stack - the stack trace, if captured
+//	%fields - all structured fields as sorted key=value pairs
+//	%colour or %color - the colour configured for the event's level
+//	%red, %bold, %reset etc. - any cfmt keyword
+//
+// A width can be put between the percent sign and the keyword. Positive widths
+// right-align the value, and negative widths left-align it: "%-8level", "%5pid".
+type Template struct {
+	format string
+	parts  []part
+}
+
+// part of a compiled template.
+type part struct {
+	kind  int
+	text  string
+	width int
+}
+
+// Template part kinds.
+const (
+	partText = iota
+	partLevel
+	partPID
+	partTime
+	partName
+	partHost
+	partSource
+	partMessage
+	partExtra
+	partField
+	partFields
+	partColour
+	partStack
+)
+
+var partKeys = map[string]int{
+	"level":  partLevel,
+	"pid":    partPID,
+	"time":   partTime,
+	"name":   partName,
+	"host":   partHost,
+	"src":    partSource,
+	"msg":    partMessage,
+	"extra":  partExtra,
+	"fields": partFields,
+	"colour": partColour,
+	"color":  partColour,
+	"stack":  partStack,
+}
+
+// Compile a format string into a reusable template.
+func Compile(f string) *Template {
+	t := &Template{format: f}
+	var lit strings.Builder
+	for len(f) > 0 {
+		if f[0] != '%' {
+			lit.WriteByte(f[0])
+			f = f[1:]
+			continue
+		}
+
+		p, rest, ok := parsePart(f)
+		if !ok {
+			// Not a keyword we know, so leave it as it was. This skips fmt keywords.
+			lit.WriteString(f[:len(f)-len(rest)])
+			f = rest
+			continue
+		}
+
+		if p.kind == partText {
+			lit.WriteString(p.text)
+		} else {
+			if lit.Len() > 0 {
+				t.parts = append(t.parts, part{kind: partText, text: lit.String()})
+				lit.Reset()
+			}
+			t.parts = append(t.parts, p)
+		}
+		f = rest
+	}
+	if lit.Len() > 0 {
+		t.parts = append(t.parts, part{kind: partText, text: lit.String()})
+	}
+	return t
+}
+
+// String returns the source format of the template.
+func (t *Template) String() string {
+	return t.format
+}
+
+// parsePart parses one keyword at the start of f, returning the part, the rest
+// of the string and whether it was recognised.
+func parsePart(f string) (part, string, bool) {
+	var p part
+	in := f[1:]
+	left := false
+	if len(in) > 0 && in[0] == '-' {
+		left = true
+		in = in[1:]
+	}
+
+	n := 0
+	for n < len(in) && in[n] >= '0' && in[n] <= '9' {
+		n++
+	}
+	if n > 0 {
+		p.width, _ = strconv.Atoi(in[:n])
+		in = in[n:]
+	}
+	if left {
+		p.width = -p.width
+	}
+
+	// %{field}
+	if len(in) > 0 && in[0] == '{' {
+		end := strings.IndexByte(in, '}')
+		if end < 0 {
+			return p, in, false
+		}
+
+		p.kind = partField
+		p.text = in[1:end]
+		return p, in[end+1:], true
+	}
+
+	n = 0
+	for n < len(in) && unicode.IsLetter(rune(in[n])) {
+		n++
+	}
+	key := in[:n]
+	in = in[n:]
+	kind, ok := partKeys[key]
+	if !ok {
+		code, ok := cfmt.Code(key)
+		if !ok {
+			return p, in, false
+		}
+
+		p.kind = partText
+		p.text = code
+		return p, in, true
+	}
+
+	p.kind = kind
+	if kind == partTime && len(in) > 0 && in[0] == '{' {
+		end := strings.IndexByte(in, '}')
+		if end > 0 {
+			p.text = in[1:end]
+			in = in[end+1:]
+		}
+	}
+	return p, in, true
+}
+
+// execute writes the event to its builder. The colours map may be nil.
+func (t *Template) execute(e *Event, colours map[uint]string) string {
+	e.Reset()
+	for _, p := range t.parts {
+		switch p.kind {
+		case partText:
+			e.WriteString(p.text)
+		case partLevel:
+			e.pad(strconv.FormatUint(uint64(e.Level), 10), p.width)
+		case partPID:
+			e.pad(strconv.Itoa(e.PID), p.width)
+		case partTime:
+			tm := e.Time
+			if tm.IsZero() {
+				tm = time.Now()
+			}
+			if p.text == "" {
+				e.pad(TimeString(tm), p.width)
+			} else {
+				e.pad(tm.Format(p.text), p.width)
+			}
+		case partName:
+			e.pad(e.Name, p.width)
+		case partHost:
+			e.pad(e.Hostname, p.width)
+		case partSource:
+			e.pad(e.Source, p.width)
+		case partMessage:
+			e.pad(e.Message, p.width)
+		case partExtra:
+			e.pad(strings.Join(e.Extra, ","), p.width)
+		case partField:
+			v, ok := e.Fields[p.text]
+			if ok {
+				e.pad(fmt.Sprint(v), p.width)
+			} else {
+				e.pad("", p.width)
+			}
+		case partFields:
+			e.pad(e.fieldString(), p.width)
+		case partColour:
+			e.WriteString(colours[e.Level])
+		case partStack:
+			e.WriteString(e.Stack)
+		}
+	}
+	e.WriteString("\n")
+	return e.String()
+}
+
+// pad writes s aligned to the width, if any.
+func (e *Event) pad(s string, width int) {
+	left := width < 0
+	if left {
+		width = -width
+	}
+
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		e.WriteString(s)
+		return
+	}
+
+	if left {
+		e.WriteString(s)
+		e.WriteString(strings.Repeat(" ", n))
+	} else {
+		e.WriteString(strings.Repeat(" ", n))
+		e.WriteString(s)
+	}
+}
+
+// fieldString returns the structured fields as sorted key=value pairs.
+func (e *Event) fieldString() string {
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(fmt.Sprint(e.Fields[k]))
+	}
+	return b.String()
+}
+
+// maxTemplates is how many formats Event.Fmt() keeps compiled. Further formats
+// are compiled on every call, so callers building formats on the fly can't grow
+// the cache without bounds.
+const maxTemplates = 64
+
+// templates caches formats passed to Event.Fmt().
+var templates = struct {
+	sync.RWMutex
+	list map[string]*Template
+}{list: make(map[string]*Template)}
+
+// cachedTemplate returns a compiled template for f, compiling it on first use.
+func cachedTemplate(f string) *Template {
+	templates.RLock()
+	t, ok := templates.list[f]
+	templates.RUnlock()
+	if ok {
+		return t
+	}
+
+	t = Compile(f)
+	templates.Lock()
+	defer templates.Unlock()
+	if len(templates.list) < maxTemplates {
+		templates.list[f] = t
+	}
+	return t
+}
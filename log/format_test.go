@@ -0,0 +1,62 @@
+package log_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Urethramancer/signor/cfmt"
+	"github.com/Urethramancer/signor/log"
+)
+
+func TestFormat(t *testing.T) {
+	e := log.Event{
+		Level:   2,
+		PID:     42,
+		Time:    time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC),
+		Name:    "app",
+		Message: "hello",
+		Fields:  map[string]interface{}{"request_id": "abc", "n": 3},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"%time{2006-01-02} %msg", "2020-03-04 hello\n"},
+		{"[%-4level] %msg", "[2   ] hello\n"},
+		{"[%4pid] %name", "[  42] app\n"},
+		{"%{request_id}: %msg", "abc: hello\n"},
+		{"%fields", "n=3 request_id=abc\n"},
+		{"%{missing}|%unknown 100%", "|%unknown 100%\n"},
+		{"%red%msg%reset", cfmt.Red + "hello" + cfmt.Reset + "\n"},
+	}
+
+	for _, tc := range tests {
+		got := e.Format(log.Compile(tc.format))
+		if got != tc.want {
+			t.Errorf("Format %q: got %q, expected %q", tc.format, got, tc.want)
+		}
+
+		got = e.Fmt(tc.format)
+		if got != tc.want {
+			t.Errorf("Fmt %q: got %q, expected %q", tc.format, got, tc.want)
+		}
+	}
+}
+
+func TestFmtMany(t *testing.T) {
+	e := log.Event{Message: "hello"}
+	for i := 0; i < 200; i++ {
+		n := strconv.Itoa(i)
+		got := e.Fmt(n + " %msg")
+		if got != n+" hello\n" {
+			t.Fatalf("Format %d: got %q", i, got)
+		}
+	}
+
+	got := e.Fmt("0 %msg")
+	if got != "0 hello\n" {
+		t.Errorf("Cached format: got %q", got)
+	}
+}
@@ -5,17 +5,18 @@ package log
 
 import (
 	"fmt"
-	"io/ioutil"
-	oldlog "log"
 	"os"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/Urethramancer/signor/cfmt"
 )
 
 // Default Logger object.
 var Default *Logger
 
 func init() {
-	oldlog.SetOutput(ioutil.Discard)
 	Default = NewLogger()
 }
 
@@ -25,10 +26,27 @@ type Logger struct {
 	msgF string
 	// errF is the format of errors. Users generally want every detail you can provide.
 	errF     string
+	msgT     *Template
+	errT     *Template
+	colours  map[uint]string
 	servers  []string
 	outFiles []*os.File
 	validOut []bool
 	logDst   byte
+
+	// asyncMu guards async, which is nil for synchronous logging.
+	asyncMu sync.RWMutex
+	async   *asyncQueue
+	samples sampler
+	exiter  exiter
+	sinks   sinks
+	enrich  enricher
+
+	// root is the logger a child created by With() writes through.
+	root *Logger
+	// fields added by With(), and the prefix they produce.
+	fields []Field
+	prefix string
 }
 
 // LogShortcuts for the lazy. Embed these for convenience.
@@ -60,6 +78,8 @@ func NewLogger() *Logger {
 	l := Logger{
 		msgF:     DetailedFormat,
 		errF:     DetailedFormat,
+		msgT:     Compile(DetailedFormat),
+		errT:     Compile(DetailedFormat),
 		servers:  make([]string, 2),
 		outFiles: []*os.File{os.Stdout, os.Stderr},
 		validOut: make([]bool, 2),
@@ -69,7 +89,10 @@ func NewLogger() *Logger {
 }
 
 // CloseFiles closes any open non-stdout/stderr files and replaces them with stdout.
+// Any queued asynchronous output is flushed first.
 func (l *Logger) CloseFiles() {
+	l = l.base()
+	l.Flush()
 	for i := 0; i < 2; i++ {
 		if l.validOut[i] {
 			l.outFiles[i].Close()
@@ -80,65 +103,100 @@ func (l *Logger) CloseFiles() {
 
 // Msg prints arbitrary formatted messages to the configured message output(s).
 func (l *Logger) Msg(f string, v ...interface{}) {
-	var b strings.Builder
-	b.WriteString(fmt.Sprintf(f, v...))
-	b.WriteString("\n")
-	if l.logDst&O_FILE == O_FILE {
-		fmt.Fprint(l.outFiles[0], b.String())
-	}
+	l.output(0, false, f, v)
 }
 
 // Printf wraps Msg for compatibility with some other loggers.
 func (l *Logger) Printf(f string, v ...interface{}) {
-	l.Msg(f, v...)
+	l.output(0, false, f, v)
 }
 
 // TMsg prints arbitrary formatted messages to the configured message output(s),
 // starting with a timestamp.
 func (l *Logger) TMsg(f string, v ...interface{}) {
-	var b strings.Builder
-	b.WriteString(NowString())
-	b.WriteRune(':')
-	b.WriteString(fmt.Sprintf(f, v...))
-	b.WriteString("\n")
-	if l.logDst&O_FILE == O_FILE {
-		fmt.Fprint(l.outFiles[0], b.String())
-	}
+	l.output(0, true, f, v)
 }
 
 // Err prints arbitrary formatted errors to the configured error output(s).
 func (l *Logger) Err(f string, v ...interface{}) {
-	var b strings.Builder
-	b.WriteString(fmt.Sprintf(f, v...))
-	b.WriteString("\n")
-	if l.logDst&O_FILE == O_FILE {
-		fmt.Fprint(l.outFiles[1], b.String())
-	}
+	l.output(1, false, f, v)
 }
 
 // TErr prints arbitrary formatted errors to the configured error output(s),
 // starting with a timestamp.
 func (l *Logger) TErr(f string, v ...interface{}) {
+	l.output(1, true, f, v)
+}
+
+// output formats a message for message (0) or error (1) output, optionally timestamped.
+func (l *Logger) output(out int, stamp bool, f string, v []interface{}) {
+	if !l.sample(uint(out), f) {
+		return
+	}
+
+	l.line(uint(out), stamp, fmt.Sprintf(f, v...))
+}
+
+// line writes a formatted message to the output for the level, and passes it on to sinks.
+func (l *Logger) line(level uint, stamp bool, msg string) {
 	var b strings.Builder
-	b.WriteString(NowString())
-	b.WriteRune(':')
-	b.WriteString(fmt.Sprintf(f, v...))
+	if stamp {
+		b.WriteString(NowString())
+		b.WriteRune(':')
+	}
+	b.WriteString(l.prefix)
+	b.WriteString(msg)
 	b.WriteString("\n")
-	if l.logDst&O_FILE == O_FILE {
-		fmt.Fprint(l.outFiles[1], b.String())
+	l.write(levelOut(level), b.String())
+	if l.hasSinks() {
+		e := &Event{
+			Level:   level,
+			Time:    time.Now(),
+			Message: msg,
+		}
+		l.mergeFields(e)
+		l.enrichEvent(e)
+		l.toSinks(e)
 	}
 }
 
+// write sends a finished line to message (0) or error (1) output,
+// either directly or via the asynchronous queue.
+func (l *Logger) write(out int, s string) {
+	l = l.base()
+	if l.logDst&O_FILE != O_FILE {
+		return
+	}
+
+	l.asyncMu.RLock()
+	defer l.asyncMu.RUnlock()
+	if l.async != nil {
+		l.async.push(l.outFiles[out], s)
+		return
+	}
+
+	f := l.outFiles[out]
+	cfmt.InterruptFor(f, func() {
+		fmt.Fprint(f, s)
+	})
+}
+
 // Log an event to an appropriate output in a configured format for that log level.
 // Level 0 defaults to stdout, anything else to stderr.
+// Fields added with With() are merged into the event without replacing its own.
 func (l *Logger) Log(e *Event) {
-	if l.logDst&O_FILE == O_FILE {
-		if e.Level == 0 {
-			fmt.Fprint(l.outFiles[0], e.Fmt(l.msgF))
-		} else {
-			fmt.Fprint(l.outFiles[1], e.Fmt(l.errF))
-		}
+	if !l.sample(e.Level, e.Message) {
+		return
 	}
+
+	l.mergeFields(e)
+	l.enrichEvent(e)
+	if e.Level == 0 {
+		l.write(0, l.msgT.execute(e, l.colours))
+	} else {
+		l.write(1, l.errT.execute(e, l.colours))
+	}
+	l.toSinks(e)
 }
 
 // SetFmt for messages and errors to the same format.
@@ -150,11 +208,31 @@ func (l *Logger) SetFmt(s string) {
 // SetLogFmt sets the output format for informational event logs.
 func (l *Logger) SetLogFmt(s string) {
 	l.msgF = s
+	l.msgT = Compile(s)
 }
 
 // SetELogFmt sets the output format for error event logs.
 func (l *Logger) SetELogFmt(s string) {
 	l.errF = s
+	l.errT = Compile(s)
+}
+
+// SetLevelColour sets the cfmt keyword (e.g. "red" or "lyellow") used by %colour
+// in event formats for the given level. A blank keyword removes the colour.
+func (l *Logger) SetLevelColour(level uint, key string) {
+	if l.colours == nil {
+		l.colours = make(map[uint]string)
+	}
+
+	if key == "" {
+		delete(l.colours, level)
+		return
+	}
+
+	code, ok := cfmt.Code(key)
+	if ok {
+		l.colours[level] = code
+	}
 }
 
 // SetLogOut sets the output methods for messages and errors.
@@ -163,6 +241,7 @@ func (l *Logger) SetELogFmt(s string) {
 // Specify O_FILE and blank files to use stdout and stderr.
 // This can be combined with either O_JSON or O_RPC.
 func (l *Logger) SetLogOut(log byte, files, servers []string) {
+	l = l.base()
 	l.logDst = log
 	l.outFiles[0] = os.Stdout
 	l.outFiles[1] = os.Stderr
@@ -186,6 +265,8 @@ func (l *Logger) SetLogOut(log byte, files, servers []string) {
 // Warn is meant to be deferred with closing operations which might return an error.
 // If t is true, the output will be timestamped with the default format of the logger.
 // Any error returns 1 to the operating system, which is considered a warning/minor error.
+// The program ends through Exit(), so shutdown hooks run first. If a custom exit
+// function returns, nothing else happens after it.
 func (l *Logger) Warn(err error, t bool) {
 	if err == nil {
 		return
@@ -196,12 +277,13 @@ func (l *Logger) Warn(err error, t bool) {
 	} else {
 		l.Err("Error: %s", err.Error())
 	}
-	os.Exit(1)
+	l.Exit(1)
 }
 
 // Fail is meant to be deferred with closing operations which might return an error.
 // If t is true, the output will be timestamped with the default format of the logger.
 // Any error returns 2 to the operating system, which is considered a major error.
+// The program ends through Exit() like Warn().
 func (l *Logger) Fail(err error, t bool) {
 	if err == nil {
 		return
@@ -212,5 +294,5 @@ func (l *Logger) Fail(err error, t bool) {
 	} else {
 		l.Err("Error: %s", err.Error())
 	}
-	os.Exit(2)
+	l.Exit(2)
 }
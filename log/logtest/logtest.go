@@ -0,0 +1,120 @@
+// Package logtest captures log output in memory for unit tests.
+package logtest
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Urethramancer/signor/log"
+)
+
+// Capture is a log.Sink which records every event it receives.
+type Capture struct {
+	sync.Mutex
+	// Logger writes nothing to files, and only sends events to the capture.
+	Logger *log.Logger
+	events []log.Event
+}
+
+// New returns a capture with its own silent logger.
+func New() *Capture {
+	c := &Capture{
+		Logger: log.NewLogger(),
+	}
+	c.Logger.SetLogOut(0, nil, nil)
+	c.Logger.SetExitFunc(log.ExitPanic)
+	c.Logger.AddSink(c)
+	return c
+}
+
+// SwapDefault replaces log.Default with a capture's logger, and restores the old
+// one when the test finishes. Swap before creating anything which copies
+// log.Default, such as server.New() or files.NewCloser().
+func SwapDefault(t testing.TB) *Capture {
+	c := New()
+	old := log.Default
+	log.Default = c.Logger
+	t.Cleanup(func() {
+		log.Default = old
+	})
+	return c
+}
+
+// Log records an event.
+func (c *Capture) Log(e *log.Event) {
+	c.Lock()
+	defer c.Unlock()
+	c.events = append(c.events, e.Clone())
+}
+
+// Events returns a copy of the recorded events.
+func (c *Capture) Events() []log.Event {
+	c.Lock()
+	defer c.Unlock()
+	list := make([]log.Event, len(c.events))
+	copy(list, c.events)
+	return list
+}
+
+// Reset clears the recorded events.
+func (c *Capture) Reset() {
+	c.Lock()
+	defer c.Unlock()
+	c.events = nil
+}
+
+// Logged returns true if an event of the level with a message containing the substring was recorded.
+func (c *Capture) Logged(level uint, substr string) bool {
+	c.Lock()
+	defer c.Unlock()
+	for _, e := range c.events {
+		if e.Level == level && strings.Contains(e.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertLogged fails the test if no matching event was recorded.
+func (c *Capture) AssertLogged(t testing.TB, level uint, substr string) {
+	t.Helper()
+	if !c.Logged(level, substr) {
+		t.Errorf("Expected level %d message containing %q, got:\n%s", level, substr, c.dump())
+	}
+}
+
+// AssertNotLogged fails the test if a matching event was recorded.
+func (c *Capture) AssertNotLogged(t testing.TB, level uint, substr string) {
+	t.Helper()
+	if c.Logged(level, substr) {
+		t.Errorf("Unexpected level %d message containing %q", level, substr)
+	}
+}
+
+// AssertField fails the test if no event of the level has the field set to value.
+// Values are compared with reflect.DeepEqual(), so slices and maps work.
+func (c *Capture) AssertField(t testing.TB, level uint, key string, value interface{}) {
+	t.Helper()
+	c.Lock()
+	defer c.Unlock()
+	for _, e := range c.events {
+		v, ok := e.Fields[key]
+		if e.Level == level && ok && reflect.DeepEqual(v, value) {
+			return
+		}
+	}
+	t.Errorf("Expected level %d event with %s=%v", level, key, value)
+}
+
+// dump lists the recorded events for failure messages.
+func (c *Capture) dump() string {
+	c.Lock()
+	defer c.Unlock()
+	var b strings.Builder
+	for _, e := range c.events {
+		b.WriteString(e.Fmt("\t%level: %msg"))
+	}
+	return b.String()
+}
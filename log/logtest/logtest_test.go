@@ -0,0 +1,68 @@
+package logtest_test
+
+import (
+	"testing"
+
+	"github.com/Urethramancer/signor/log/logtest"
+)
+
+func TestCapture(t *testing.T) {
+	logs := logtest.New()
+	logs.Logger.Msg("hello %s", "world")
+	logs.Logger.Err("broken %d", 42)
+
+	events := logs.Events()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	if events[0].Level != 0 || events[0].Message != "hello world" {
+		t.Errorf("Unexpected first event: level %d, %q", events[0].Level, events[0].Message)
+	}
+
+	if events[1].Level != 1 || events[1].Message != "broken 42" {
+		t.Errorf("Unexpected second event: level %d, %q", events[1].Level, events[1].Message)
+	}
+
+	events[0].Message = "changed"
+	if logs.Events()[0].Message != "hello world" {
+		t.Errorf("Events() didn't return a copy")
+	}
+}
+
+func TestCaptureLevels(t *testing.T) {
+	logs := logtest.New()
+	logs.Logger.Msg("hello")
+	logs.Logger.Err("broken")
+
+	if !logs.Logged(0, "hell") || !logs.Logged(1, "broken") {
+		t.Errorf("Logged() missed recorded events")
+	}
+
+	if logs.Logged(1, "hello") || logs.Logged(0, "broken") {
+		t.Errorf("Logged() matched the wrong level")
+	}
+
+	logs.AssertLogged(t, 0, "hello")
+	logs.AssertNotLogged(t, 0, "broken")
+	logs.AssertNotLogged(t, 1, "hello")
+}
+
+func TestCaptureReset(t *testing.T) {
+	logs := logtest.New()
+	logs.Logger.Msg("hello")
+	logs.Reset()
+	if len(logs.Events()) != 0 {
+		t.Errorf("Reset() left %d events", len(logs.Events()))
+	}
+
+	logs.AssertNotLogged(t, 0, "hello")
+	logs.Logger.Msg("again")
+	logs.AssertLogged(t, 0, "again")
+}
+
+func TestAssertFieldSlice(t *testing.T) {
+	logs := logtest.New()
+	logs.Logger.With("tags", []string{"a", "b"}).Msg("tagged")
+	logs.AssertField(t, 0, "tags", []string{"a", "b"})
+}
@@ -0,0 +1,227 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Sampling limits how much a noisy level can log. Messages are grouped by key,
+// which is the format string for Msg()/Err() and friends, the message for Log(),
+// or the calling file and line if ByCaller is set.
+//
+// Within each Tick the First messages per key are logged, and after that only
+// every Thereafter'th. Anything passing the sampler must also get a token from a
+// bucket filled at Rate tokens per second, holding at most Burst tokens.
+//
+// Suppressed messages are counted, and a summary line is logged when the key's
+// next window starts, or on Flush().
+type Sampling struct {
+	// First messages per key and tick are always logged.
+	First int
+	// Thereafter every Nth message is logged. Zero drops everything past First.
+	Thereafter int
+	// Tick is the sampling window. Defaults to one second.
+	Tick time.Duration
+	// Rate of the token bucket in messages per second. Zero disables rate limiting.
+	Rate float64
+	// Burst is the size of the token bucket. Defaults to Rate rounded up.
+	Burst int
+	// ByCaller groups messages by the file and line they were logged from.
+	ByCaller bool
+}
+
+// sampler state for all levels of a logger.
+type sampler struct {
+	sync.Mutex
+	levels map[uint]*levelSampler
+}
+
+// levelSampler tracks one level.
+type levelSampler struct {
+	Sampling
+	keys   map[string]*sampleKey
+	tokens float64
+	filled time.Time
+	swept  time.Time
+}
+
+// sampleKey is the window for one message key.
+type sampleKey struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// SetSampling configures sampling and rate limiting for a level.
+// Msg(), Printf() and TMsg() count as level 0, Err() and TErr() as level 1,
+// and events use their own level. A nil configuration removes sampling.
+// Summaries of messages suppressed under the old configuration are logged first.
+func (l *Logger) SetSampling(level uint, s *Sampling) {
+	l = l.base()
+	l.samples.Lock()
+	var summaries []string
+	old, ok := l.samples.levels[level]
+	if ok {
+		summaries = old.sweep(time.Now(), true)
+	}
+	l.setSampling(level, s)
+	l.samples.Unlock()
+
+	for _, s := range summaries {
+		l.write(levelOut(level), s)
+	}
+}
+
+// setSampling replaces the level's configuration. The caller holds the lock.
+func (l *Logger) setSampling(level uint, s *Sampling) {
+	if s == nil {
+		delete(l.samples.levels, level)
+		return
+	}
+
+	if l.samples.levels == nil {
+		l.samples.levels = make(map[uint]*levelSampler)
+	}
+	ls := &levelSampler{
+		Sampling: *s,
+		keys:     make(map[string]*sampleKey),
+		filled:   time.Now(),
+	}
+	if ls.Tick <= 0 {
+		ls.Tick = time.Second
+	}
+	if ls.Burst < 1 {
+		ls.Burst = int(ls.Rate)
+		if float64(ls.Burst) < ls.Rate {
+			ls.Burst++
+		}
+	}
+	ls.tokens = float64(ls.Burst)
+	l.samples.levels[level] = ls
+}
+
+// sample decides if a message should be logged, and logs summaries of suppressed
+// messages as their windows expire.
+func (l *Logger) sample(level uint, key string) bool {
+	root := l.base()
+	root.samples.Lock()
+	ls, ok := root.samples.levels[level]
+	if !ok {
+		root.samples.Unlock()
+		return true
+	}
+
+	if ls.ByCaller {
+		f, _ := callerFrames().Next()
+		if f.PC != 0 {
+			key = f.File + ":" + strconv.Itoa(f.Line)
+		}
+	}
+
+	now := time.Now()
+	var summaries []string
+	if now.Sub(ls.swept) >= ls.Tick {
+		summaries = ls.sweep(now, false)
+	}
+
+	k, ok := ls.keys[key]
+	if !ok {
+		k = &sampleKey{start: now}
+		ls.keys[key] = k
+	} else if now.Sub(k.start) >= ls.Tick {
+		if k.suppressed > 0 {
+			summaries = append(summaries, summary(key, k.suppressed))
+		}
+		k.start = now
+		k.count = 0
+		k.suppressed = 0
+	}
+
+	k.count++
+	pass := k.count <= ls.First
+	if !pass && ls.Thereafter > 0 {
+		pass = (k.count-ls.First)%ls.Thereafter == 0
+	}
+	if pass && ls.Rate > 0 {
+		pass = ls.take(now)
+	}
+	if !pass {
+		k.suppressed++
+	}
+	root.samples.Unlock()
+
+	for _, s := range summaries {
+		root.write(levelOut(level), s)
+	}
+	return pass
+}
+
+// take a token from the bucket, refilling it first.
+func (ls *levelSampler) take(now time.Time) bool {
+	ls.tokens += now.Sub(ls.filled).Seconds() * ls.Rate
+	ls.filled = now
+	if ls.tokens > float64(ls.Burst) {
+		ls.tokens = float64(ls.Burst)
+	}
+	if ls.tokens < 1 {
+		return false
+	}
+
+	ls.tokens--
+	return true
+}
+
+// sweep removes expired keys and returns summaries for those with suppressed
+// messages. If all is true, every key with suppressed messages is summarised.
+func (ls *levelSampler) sweep(now time.Time, all bool) []string {
+	var list []string
+	ls.swept = now
+	for key, k := range ls.keys {
+		expired := now.Sub(k.start) >= ls.Tick
+		if (expired || all) && k.suppressed > 0 {
+			list = append(list, summary(key, k.suppressed))
+			k.suppressed = 0
+		}
+		if expired {
+			delete(ls.keys, key)
+		}
+	}
+	return list
+}
+
+// flushSamples logs summaries for every key with suppressed messages.
+func (l *Logger) flushSamples() {
+	l = l.base()
+	type pending struct {
+		level uint
+		list  []string
+	}
+	var all []pending
+	l.samples.Lock()
+	for level, ls := range l.samples.levels {
+		all = append(all, pending{level: level, list: ls.sweep(time.Now(), true)})
+	}
+	l.samples.Unlock()
+
+	for _, p := range all {
+		for _, s := range p.list {
+			l.write(levelOut(p.level), s)
+		}
+	}
+}
+
+// summary line for suppressed messages.
+func summary(key string, n int) string {
+	return fmt.Sprintf("Suppressed %d messages like %q\n", n, key)
+}
+
+// levelOut returns the output index for a level.
+func levelOut(level uint) int {
+	if level == 0 {
+		return 0
+	}
+
+	return 1
+}
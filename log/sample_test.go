@@ -0,0 +1,100 @@
+package log_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Urethramancer/signor/log"
+)
+
+func TestSampling(t *testing.T) {
+	dir := t.TempDir()
+	errs := filepath.Join(dir, "err.log")
+	l := log.NewLogger()
+	l.SetLogOut(log.O_FILE, []string{filepath.Join(dir, "msg.log"), errs}, nil)
+	defer l.CloseFiles()
+
+	l.SetSampling(1, &log.Sampling{First: 3, Thereafter: 10, Tick: time.Hour})
+	for i := 0; i < 103; i++ {
+		l.Err("storm %d", i)
+	}
+	l.Flush()
+
+	data, err := os.ReadFile(errs)
+	if err != nil {
+		t.Fatalf("Couldn't read %s: %s", errs, err.Error())
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	// 3 first, then every 10th of the remaining 100, then the summary.
+	if len(lines) != 14 {
+		t.Fatalf("Expected 14 lines, got %d:\n%s", len(lines), string(data))
+	}
+
+	want := `Suppressed 90 messages like "storm %d"`
+	if lines[13] != want {
+		t.Errorf("Got summary %q, expected %q", lines[13], want)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	dir := t.TempDir()
+	msgs := filepath.Join(dir, "msg.log")
+	l := log.NewLogger()
+	l.SetLogOut(log.O_FILE, []string{msgs, filepath.Join(dir, "err.log")}, nil)
+	defer l.CloseFiles()
+
+	l.SetSampling(0, &log.Sampling{First: 1000, Rate: 0.001, Burst: 5, ByCaller: true})
+	for i := 0; i < 50; i++ {
+		l.Msg("tick %d", i)
+	}
+	l.SetSampling(0, nil)
+	l.Msg("done")
+
+	data, err := os.ReadFile(msgs)
+	if err != nil {
+		t.Fatalf("Couldn't read %s: %s", msgs, err.Error())
+	}
+
+	// 5 from the burst, the summary logged by SetSampling(), then "done".
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("Expected 7 lines, got %d:\n%s", len(lines), string(data))
+	}
+
+	if !strings.HasPrefix(lines[5], "Suppressed 45 messages like") || !strings.Contains(lines[5], "sample_test.go:") {
+		t.Errorf("Unexpected summary %q", lines[5])
+	}
+}
+
+func TestSamplingStdLogger(t *testing.T) {
+	dir := t.TempDir()
+	errs := filepath.Join(dir, "err.log")
+	l := log.NewLogger()
+	l.SetLogOut(log.O_FILE, []string{filepath.Join(dir, "msg.log"), errs}, nil)
+	defer l.CloseFiles()
+
+	l.SetSampling(1, &log.Sampling{First: 1, Tick: time.Hour, ByCaller: true})
+	std := l.StdLogger(1)
+	for i := 0; i < 3; i++ {
+		std.Printf("first %d", i)
+	}
+	for i := 0; i < 3; i++ {
+		std.Printf("second %d", i)
+	}
+	l.Flush()
+
+	data, err := os.ReadFile(errs)
+	if err != nil {
+		t.Fatalf("Couldn't read %s: %s", errs, err.Error())
+	}
+
+	// Each call site is its own key, rather than the bridge.
+	s := string(data)
+	if !strings.Contains(s, "first 0") || !strings.Contains(s, "second 0") || strings.Count(s, "sample_test.go:") != 2 {
+		t.Errorf("Unexpected output:\n%s", s)
+	}
+}
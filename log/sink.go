@@ -0,0 +1,57 @@
+package log
+
+import "sync"
+
+// Sink receives every message and event a logger writes, in addition to its
+// normal outputs. Msg(), Printf() and TMsg() are delivered as level 0 events,
+// and Err() and TErr() as level 1, with the formatted text as the message.
+// Sinks are called synchronously, and must not keep the event after returning;
+// use Event.Clone() to hold on to it.
+type Sink interface {
+	Log(e *Event)
+}
+
+// sinks registered on a logger.
+type sinks struct {
+	sync.RWMutex
+	list []Sink
+}
+
+// AddSink adds a sink to the logger and any children sharing its outputs.
+func (l *Logger) AddSink(s Sink) {
+	l = l.base()
+	l.sinks.Lock()
+	defer l.sinks.Unlock()
+	l.sinks.list = append(l.sinks.list, s)
+}
+
+// RemoveSink removes a sink added with AddSink().
+func (l *Logger) RemoveSink(s Sink) {
+	l = l.base()
+	l.sinks.Lock()
+	defer l.sinks.Unlock()
+	for i, x := range l.sinks.list {
+		if x == s {
+			l.sinks.list = append(l.sinks.list[:i], l.sinks.list[i+1:]...)
+			return
+		}
+	}
+}
+
+// hasSinks returns true if there is anything to send events to.
+func (l *Logger) hasSinks() bool {
+	l = l.base()
+	l.sinks.RLock()
+	defer l.sinks.RUnlock()
+	return len(l.sinks.list) > 0
+}
+
+// toSinks passes an event to all sinks.
+func (l *Logger) toSinks(e *Event) {
+	l = l.base()
+	l.sinks.RLock()
+	defer l.sinks.RUnlock()
+	for _, s := range l.sinks.list {
+		s.Log(e)
+	}
+}
@@ -12,7 +12,11 @@ const (
 
 // NowString returns a very detailed time string.
 func NowString() string {
-	t := time.Now()
+	return TimeString(time.Now())
+}
+
+// TimeString returns a very detailed time string for t.
+func TimeString(t time.Time) string {
 	return fmt.Sprintf(timeFmt, t.Weekday().String()[0:3], t.Month().String()[0:3], t.Day(),
 		t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Year())
 }
@@ -34,6 +34,6 @@ func main() {
 	err = a.RunCommand(false)
 	if err != nil {
 		log.Default.Msg("Error running: %s", err.Error())
-		os.Exit(2)
+		log.Default.Exit(2)
 	}
 }
@@ -3,6 +3,7 @@ package server
 import (
 	"sync"
 
+	"github.com/Urethramancer/signor/files"
 	"github.com/Urethramancer/signor/log"
 	"github.com/Urethramancer/signor/server/web"
 )
@@ -17,6 +18,8 @@ type Server struct {
 	Name       string
 	webservers map[string]*web.Web
 	quit       chan bool
+	pidPath    string
+	pid        *files.PIDFile
 }
 
 // New server instance creation.
@@ -26,15 +29,28 @@ func New(name string) *Server {
 		webservers: make(map[string]*web.Web),
 		quit:       make(chan bool, 1),
 	}
-	s.Logger = log.Default
-	s.L = log.Default.TMsg
-	s.E = log.Default.TErr
+	s.setLogger(log.Default)
 
 	return &s
 }
 
+// SetPIDFile makes Start write the process ID to a file, and refuse to start
+// if another live process already holds it.
+func (s *Server) SetPIDFile(path string) {
+	s.pidPath = path
+}
+
 // Start all configured sub-servers.
 func (s *Server) Start() error {
+	if s.pidPath != "" {
+		pid, err := files.WritePIDFile(s.pidPath)
+		if err != nil {
+			return err
+		}
+
+		s.pid = pid
+	}
+
 	s.L("Starting server '%s'.", s.Name)
 
 	s.Add(1)
@@ -46,27 +62,50 @@ func (s *Server) Start() error {
 	return nil
 }
 
-// Stop all sub-servers.
+// Stop all sub-servers, remove the PID file and flush any asynchronous log output.
+// The PID file is removed and the log flushed even if a web server fails to stop.
 func (s *Server) Stop() error {
+	defer s.Logger.Flush()
+	defer s.removePIDFile()
 	s.quit <- true
 	err := s.StopWeb()
 	if err != nil {
 		return err
 	}
+
 	s.Wait()
 	return nil
 }
 
+// removePIDFile removes the PID file, if the server wrote one.
+func (s *Server) removePIDFile() {
+	if s.pid == nil {
+		return
+	}
+
+	err := s.pid.Remove()
+	s.pid = nil
+	if err != nil {
+		s.E("Couldn't remove PID file: %s", err.Error())
+	}
+}
+
 // SetLogger changes the logger object and sets the message shortcuts for convenience.
+// The server logs through a child logger with a "server" field, which web servers
+// replace with their own.
 func (s *Server) SetLogger(l *log.Logger) {
-	s.Logger = l
-	s.L = l.TMsg
-	s.E = l.TErr
+	s.setLogger(l)
 	for _, w := range s.webservers {
-		w.SetLogger(l)
+		w.SetLogger(s.Logger)
 	}
 }
 
+func (s *Server) setLogger(l *log.Logger) {
+	s.Logger = l.With("server", s.Name)
+	s.L = s.Logger.TMsg
+	s.E = s.Logger.TErr
+}
+
 // AddWebServer to the server.
 func (s *Server) AddWebServer(address, port string, secure bool) *web.Web {
 	w := web.New(address, port, s.Logger, secure)
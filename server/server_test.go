@@ -0,0 +1,56 @@
+package server_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/Urethramancer/signor/files"
+	"github.com/Urethramancer/signor/log/logtest"
+	"github.com/Urethramancer/signor/server"
+)
+
+func TestServer(t *testing.T) {
+	logs := logtest.SwapDefault(t)
+	s := server.New("test")
+	err := s.Start()
+	if err != nil {
+		t.Fatalf("Couldn't start server: %s", err.Error())
+	}
+
+	err = s.Stop()
+	if err != nil {
+		t.Fatalf("Couldn't stop server: %s", err.Error())
+	}
+
+	logs.AssertLogged(t, 0, "Starting server 'test'")
+	logs.AssertLogged(t, 0, "Quitting server 'test'")
+	logs.AssertField(t, 0, "server", "test")
+}
+
+func TestServerPIDFile(t *testing.T) {
+	logtest.SwapDefault(t)
+	fn := filepath.Join(t.TempDir(), "test.pid")
+	s := server.New("test")
+	s.SetPIDFile(fn)
+	err := s.Start()
+	if err != nil {
+		t.Fatalf("Couldn't start server: %s", err.Error())
+	}
+
+	s2 := server.New("test")
+	s2.SetPIDFile(fn)
+	err = s2.Start()
+	if !errors.Is(err, files.ErrAlreadyRunning) {
+		t.Errorf("Expected ErrAlreadyRunning, got %v", err)
+	}
+
+	err = s.Stop()
+	if err != nil {
+		t.Fatalf("Couldn't stop server: %s", err.Error())
+	}
+
+	if files.Exists(fn) {
+		t.Errorf("PID file not removed")
+	}
+}
@@ -25,10 +25,20 @@ type Site struct {
 }
 
 // SetLogger changes the logger object and sets the message shortcuts for convenience.
+// The site logs through a child logger with a "site" field.
 func (s *Site) SetLogger(l *log.Logger) {
-	s.Logger = l
-	s.L = l.TMsg
-	s.E = l.TErr
+	s.Logger = l.With("site", s.Domain)
+	s.L = s.Logger.TMsg
+	s.E = s.Logger.TErr
+}
+
+// handle wraps a handler to make the site's logger available from the request context
+// via log.FromContext().
+func (s *Site) handle(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := log.NewContext(r.Context(), s.Logger)
+		h(w, r.WithContext(ctx))
+	}
 }
 
 func enableCors(wr *http.ResponseWriter) {
@@ -1,5 +1,6 @@
 package web
 
 const (
-	ErrSiteExists = "site already exists"
+	ErrSiteExists     = "site already exists"
+	ErrNoCertificates = "no certificates loaded"
 )
@@ -3,13 +3,13 @@ package web
 import (
 	"context"
 	"crypto/tls"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"net"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"sync"
 	"time"
@@ -41,6 +41,9 @@ type Web struct {
 	//
 	sites   map[string]*Site
 	running bool
+	certMu  sync.RWMutex
+	certs   []tls.Certificate
+	watcher *files.Watcher
 }
 
 // Timeouts for web server.
@@ -78,9 +81,7 @@ func (w *Web) Init(l *log.Logger) {
 	}
 
 	w.sites = make(map[string]*Site)
-	w.Logger = l
-	w.L = l.TMsg
-	w.E = l.TErr
+	w.setLogger(l)
 
 	w.IdleTimeout = time.Second * 30
 	w.ReadTimeout = time.Second * 10
@@ -101,20 +102,17 @@ func (w *Web) Init(l *log.Logger) {
 				tls.TLS_AES_256_GCM_SHA384,
 				tls.TLS_CHACHA20_POLY1305_SHA256,
 			},
+			GetCertificate: w.getCertificate,
 		}
 	}
 	http.HandleFunc("/", w.defaultHandler)
 }
 
-// NewFromFile creates a web server based on a JSON configuration file.
+// NewFromFile creates a web server based on a JSON configuration file, which may
+// use comments, ${VAR} and $include like any files.LoadJSONConfig() file.
 func NewFromFile(name string, logger *log.Logger) (*Web, error) {
-	in, err := ioutil.ReadFile(name)
-	if err != nil {
-		return nil, err
-	}
-
 	var w Web
-	err = json.Unmarshal(in, &w)
+	err := files.LoadJSONConfig(name, &w)
 	if err != nil {
 		return nil, err
 	}
@@ -128,37 +126,107 @@ func NewFromFile(name string, logger *log.Logger) (*Web, error) {
 }
 
 // SetLogger changes the logger object and sets the message shortcuts for convenience.
+// The web server and its sites log through a child logger with a "server" field.
 func (w *Web) SetLogger(l *log.Logger) {
 	w.Lock()
 	defer w.Unlock()
-	w.Logger = l
-	w.L = l.TMsg
-	w.E = l.TErr
+	w.setLogger(l)
+	for _, s := range w.sites {
+		s.SetLogger(w.Logger)
+	}
+}
+
+func (w *Web) setLogger(l *log.Logger) {
+	w.Logger = l.With("server", "web:"+w.Port)
+	w.L = w.Logger.TMsg
+	w.E = w.Logger.TErr
+	w.ErrorLog = w.Logger.StdLogger(log.LevelWarn)
 }
 
 // AddCertificate from loaded certificate.
 func (w *Web) AddCertificate(cert tls.Certificate) {
-	w.TLSConfig.Certificates = append(w.TLSConfig.Certificates, cert)
-	w.TLSConfig.BuildNameToCertificate()
+	w.certMu.Lock()
+	defer w.certMu.Unlock()
+	w.certs = append(w.certs, cert)
 }
 
 // RebuildCertificates reloads the certificates from all sites.
+// The old certificates stay in use if any site fails to load.
 func (w *Web) RebuildCertificates() error {
+	w.RLock()
+	defer w.RUnlock()
+	return w.rebuildCertificates()
+}
+
+// rebuildCertificates does the work for RebuildCertificates(). The caller holds the lock.
+func (w *Web) rebuildCertificates() error {
+	var certs []tls.Certificate
 	for _, s := range w.sites {
 		cert, err := tls.LoadX509KeyPair(s.Certificate, s.Key)
 		if err != nil {
 			return err
 		}
 
-		w.AddCertificate(cert)
+		certs = append(certs, cert)
+	}
+
+	w.certMu.Lock()
+	defer w.certMu.Unlock()
+	w.certs = certs
+	return nil
+}
+
+// getCertificate picks the first certificate matching the client's hello,
+// or the first certificate if none match.
+func (w *Web) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	w.certMu.RLock()
+	defer w.certMu.RUnlock()
+	if len(w.certs) == 0 {
+		return nil, errors.New(ErrNoCertificates)
+	}
+
+	for i := range w.certs {
+		if hello.SupportsCertificate(&w.certs[i]) == nil {
+			return &w.certs[i], nil
+		}
 	}
-	w.TLSConfig.BuildNameToCertificate()
+	return &w.certs[0], nil
+}
+
+// watchCertificates reloads all certificates when any site's key or certificate changes.
+// The caller holds the lock.
+func (w *Web) watchCertificates() error {
+	watcher := files.NewWatcher(time.Second)
+	for _, s := range w.sites {
+		for _, fn := range []string{s.Certificate, s.Key} {
+			err := watcher.Add(fn)
+			if err != nil {
+				watcher.Close()
+				return err
+			}
+		}
+	}
+
+	w.watcher = watcher
+	go func() {
+		for range watcher.Events {
+			err := w.RebuildCertificates()
+			if err != nil {
+				w.E("Couldn't reload certificates: %s", err.Error())
+				continue
+			}
+
+			w.L("Reloaded certificates")
+		}
+	}()
 	return nil
 }
 
 // AddSite to a web server.
 // This is done on the fly without need of any restarting.
 func (w *Web) AddSite(s *Site) error {
+	w.Lock()
+	defer w.Unlock()
 	_, ok := w.sites[s.Domain]
 	if ok {
 		return errors.New(ErrSiteExists)
@@ -170,64 +238,82 @@ func (w *Web) AddSite(s *Site) error {
 			return err
 		}
 
-		//TODO: Watch key+cert for changes and automatically reload.
 		//TODO: Let's Encrypt support.
 		w.AddCertificate(cert)
+		if w.watcher != nil {
+			for _, fn := range []string{s.Certificate, s.Key} {
+				err = w.watcher.Add(fn)
+				if err != nil {
+					w.E("Couldn't watch %s for changes: %s", fn, err.Error())
+				}
+			}
+		}
 	}
+	s.SetLogger(w.Logger)
 	w.sites[s.Domain] = s
-	http.HandleFunc(s.Domain+"/", s.DefaultHandler)
+	http.HandleFunc(s.Domain+"/", s.handle(s.DefaultHandler))
 	return nil
 }
 
-// Start the webserver.
+// Start the webserver. It blocks until the server is stopped.
 func (w *Web) Start() {
-	if w.running {
+	listener := w.listen()
+	if listener == nil {
 		return
 	}
 
+	w.Add(1)
+	err := w.Serve(listener)
+	if err != nil {
+		w.E("Web server error: %s", err.Error())
+	}
+	w.Done()
+}
+
+// listen prepares certificates and returns the listener, or nil if the server is
+// already running or something failed.
+func (w *Web) listen() net.Listener {
 	w.Lock()
 	defer w.Unlock()
-	w.running = true
+	if w.running {
+		return nil
+	}
 
-	var err error
 	addr := net.JoinHostPort(w.Address, w.Port)
 	w.L("Starting web server on %s (secure=%t)", addr, w.Secure)
-	if w.Secure {
-		err = w.RebuildCertificates()
-		if err != nil {
-			w.E("Certificate error: %s", err.Error())
-			return
-		}
-
-		listener, err := tls.Listen("tcp", addr, w.TLSConfig)
+	if !w.Secure {
+		listener, err := net.Listen("tcp", addr)
 		if err != nil {
-			w.running = false
-			w.E("TLS listener error: %s", err.Error())
-			return
+			w.E("Listener error: %s", err.Error())
+			return nil
 		}
 
-		w.Add(1)
-		err = w.Serve(listener)
-		if err != nil {
-			w.E("Web server error: %s", err.Error())
-		}
-		w.Done()
-		return
+		w.running = true
+		return listener
 	}
 
-	listener, err := net.Listen("tcp", addr)
+	err := w.rebuildCertificates()
 	if err != nil {
-		w.running = false
-		w.E("Listener error: %s", err.Error())
-		return
+		w.E("Certificate error: %s", err.Error())
+		return nil
 	}
 
-	w.Add(1)
-	err = w.Serve(listener)
+	listener, err := tls.Listen("tcp", addr, w.TLSConfig)
 	if err != nil {
-		w.E("Web server error: %s", err.Error())
+		w.E("TLS listener error: %s", err.Error())
+		return nil
 	}
-	w.Done()
+
+	// Only watch once there's a listener, so a failed start has nothing to leak.
+	if w.watcher == nil {
+		err = w.watchCertificates()
+		if err != nil {
+			w.E("Couldn't watch certificates: %s", err.Error())
+		}
+	}
+
+	w.running = true
+	return listener
 }
 
 // Stop the webserver and try to wait until all connections are done.
@@ -237,12 +323,17 @@ func (w *Web) Stop() error {
 	ctx, cancel := context.WithTimeout(context.Background(), nonZeroDuration(w.Timeouts.Shutdown, time.Second*30))
 	defer cancel()
 	err := w.Shutdown(ctx)
-	if err != nil {
-		return err
-	}
 
+	// The server is unusable after Shutdown() either way, so release everything
+	// even if connections were still open when it gave up.
+	w.Lock()
+	defer w.Unlock()
 	w.running = false
-	return nil
+	if w.watcher != nil {
+		w.watcher.Close()
+		w.watcher = nil
+	}
+	return err
 }
 
 // defaultHandler when no sites are configured on a requested URL.
@@ -256,22 +347,23 @@ func (w *Web) LoadSites() error {
 		return os.ErrNotExist
 	}
 
-	dir, err := ioutil.ReadDir(w.SitePath)
+	return w.LoadSitesFS(files.OS, w.SitePath)
+}
+
+// LoadSitesFS from JSON files in a directory of a filesystem (non-recursively).
+func (w *Web) LoadSitesFS(fsys fs.FS, dir string) error {
+	list, err := fs.ReadDir(fsys, dir)
 	if err != nil {
 		return err
 	}
 
-	for _, fi := range dir {
+	for _, fi := range list {
 		if fi.IsDir() {
 			continue
 		}
-		fn := filepath.Join(w.SitePath, fi.Name())
-		in, err := ioutil.ReadFile(fn)
-		if err != nil {
-			return err
-		}
+
 		var site Site
-		err = json.Unmarshal(in, &site)
+		err = files.LoadJSONConfigFS(fsys, joinDir(fsys, dir, fi.Name()), &site)
 		if err != nil {
 			return err
 		}
@@ -284,3 +376,12 @@ func (w *Web) LoadSites() error {
 	}
 	return nil
 }
+
+// joinDir joins a directory and name with OS separators for the OS filesystem.
+func joinDir(fsys fs.FS, dir, name string) string {
+	if files.IsOS(fsys) {
+		return filepath.Join(dir, name)
+	}
+
+	return path.Join(dir, name)
+}
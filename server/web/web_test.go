@@ -0,0 +1,88 @@
+package web_test
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Urethramancer/signor/log/logtest"
+	"github.com/Urethramancer/signor/server/web"
+)
+
+var (
+	testRun  atomic.Int32
+	testOnce sync.Once
+	testWeb  *web.Web
+	testLogs *logtest.Capture
+)
+
+// newWeb returns the package's web server. Servers register handlers on the
+// default mux, so there can only be one.
+func newWeb() (*web.Web, *logtest.Capture) {
+	testOnce.Do(func() {
+		testLogs = logtest.New()
+		testWeb = web.New("127.0.0.1", "0", testLogs.Logger, false)
+	})
+	return testWeb, testLogs
+}
+
+func TestAddSiteRunning(t *testing.T) {
+	w, _ := newWeb()
+	run := strconv.Itoa(int(testRun.Add(1)))
+	done := make(chan struct{})
+	go func() {
+		w.Start()
+		close(done)
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := w.AddSite(&web.Site{Domain: "site" + strconv.Itoa(i) + ".run" + run + ".example.com"})
+			if err != nil {
+				t.Errorf("Couldn't add site: %s", err.Error())
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	err := w.AddSite(&web.Site{Domain: "site0.run" + run + ".example.com"})
+	if err == nil {
+		t.Errorf("Adding a site twice should fail")
+	}
+
+	err = w.Stop()
+	if err != nil {
+		t.Fatalf("Couldn't stop web server: %s", err.Error())
+	}
+	<-done
+}
+
+func TestLoadSitesFS(t *testing.T) {
+	w, logs := newWeb()
+	domain := "fs.run" + strconv.Itoa(int(testRun.Add(1))) + ".example.com"
+	t.Setenv("WEB_TEST_OWNER", "admin")
+	fsys := fstest.MapFS{
+		"conf/sites/site.json":     {Data: []byte("// Test site.\n{\"domain\": \"" + domain + "\", \"owner\": \"${WEB_TEST_OWNER}\"}")},
+		"conf/sites/old/skip.json": {Data: []byte(`not json`)},
+	}
+	err := w.LoadSitesFS(fsys, "conf/sites")
+	if err != nil {
+		t.Fatalf("Couldn't load sites: %s", err.Error())
+	}
+
+	logs.AssertLogged(t, 0, "Web: Loaded "+domain)
+	err = w.AddSite(&web.Site{Domain: domain})
+	if err == nil {
+		t.Errorf("Site %s wasn't added", domain)
+	}
+
+	err = w.LoadSitesFS(fsys, "missing")
+	if err == nil {
+		t.Errorf("Loading a missing directory should fail")
+	}
+}
@@ -1,25 +1,49 @@
 package stringer
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Stringer extends strings.Builder with varargs-based write methods.
 type Stringer struct {
 	strings.Builder
+	options
+}
+
+// options for writing slices and maps, shared by Stringer and Writer.
+type options struct {
 	sliceComma bool
 	mapComma   bool
 	comma      byte
 	equals     byte
+	fixed      bool
+	precision  int
+	// visiting holds the pointers being written, to stop at cycles.
+	visiting map[uintptr]bool
 }
 
-func New() *Stringer {
-	s := Stringer{
+// stringWriter is what writeX needs from Stringer and Writer.
+type stringWriter interface {
+	WriteString(string) (int, error)
+	WriteByte(byte) error
+}
+
+func defaultOptions() options {
+	return options{
 		comma:  ',',
 		equals: '=',
 	}
+}
+
+func New() *Stringer {
+	s := Stringer{
+		options: defaultOptions(),
+	}
 	return &s
 }
 
@@ -49,6 +73,10 @@ func (s *Stringer) SetEquals(e byte) *Stringer {
 
 // WriteStrings writes any number of strings in one go.
 func (s *Stringer) WriteStrings(v ...string) (int, error) {
+	return writeStrings(s, v)
+}
+
+func writeStrings(s stringWriter, v []string) (int, error) {
 	var err error
 	var size, c int
 
@@ -64,15 +92,36 @@ func (s *Stringer) WriteStrings(v ...string) (int, error) {
 
 // WriteI writes any number of different types at once.
 // Special notes:
-// Integers - int and int64 are the only approved types, and untyped whole numbers will be parsed as int
-// Floating point numbers - all numbers with a decimal point are interpreted as float64, with the fewest necessary decimal places
-// Maps & slices - commas are not on by default, and maps will have "=" between each key-value pair
+// Integers - all sizes, signed and unsigned, as numbers; runes are int32, so convert them with string() first
+// Floating point numbers - float32 and float64, with the fewest necessary decimal places unless SetPrecision() is used
+// Pointers - the value pointed to is written, nil pointers and interfaces as "<nil>", and pointers back to
+// a value already being written as their address
+// Errors, fmt.Stringers and time.Time - the Error() or String() result, and times in RFC 3339 format
+// Maps & slices - commas are not on by default, and maps will have "=" between each key-value pair, sorted by key
+// Structures - exported fields are written like a map, in order
+// []byte is written as raw bytes, and anything else is formatted by fmt.Sprint()
 func (s *Stringer) WriteI(v ...interface{}) (int, error) {
+	return s.options.writeI(s, v)
+}
+
+// SetPrecision sets the number of decimal places for floating point numbers.
+// A negative precision uses the fewest necessary, which is the default.
+func (s *Stringer) SetPrecision(p int) *Stringer {
+	s.setPrecision(p)
+	return s
+}
+
+func (o *options) setPrecision(p int) {
+	o.fixed = p >= 0
+	o.precision = p
+}
+
+func (o *options) writeI(s stringWriter, v []interface{}) (int, error) {
 	var err error
 	var size, c int
 
 	for _, x := range v {
-		c, err = s.writeX(x)
+		c, err = o.writeX(s, reflect.ValueOf(x))
 		size += c
 		if err != nil {
 			return size, err
@@ -81,76 +130,203 @@ func (s *Stringer) WriteI(v ...interface{}) (int, error) {
 	return size, nil
 }
 
+var (
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	timeType     = reflect.TypeOf(time.Time{})
+	bytesType    = reflect.TypeOf([]byte(nil))
+)
+
 // writeX can recurse deeply.
-func (s *Stringer) writeX(x interface{}) (int, error) {
-	var err error
-	var size, c int
-	switch reflect.TypeOf(x).Kind() {
-	case reflect.Bool:
-		t := reflect.ValueOf(x).Interface().(bool)
-		if t {
-			c, err = s.WriteString("true")
-		} else {
-			c, err = s.WriteString("false")
+func (o *options) writeX(s stringWriter, v reflect.Value) (int, error) {
+	if !v.IsValid() {
+		return s.WriteString("<nil>")
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return s.WriteString("<nil>")
+		}
+	}
+
+	// Values of unexported struct fields can't be turned back into interfaces.
+	if v.CanInterface() {
+		t := v.Type()
+		switch {
+		case t == timeType || (t.Kind() == reflect.Ptr && t.Elem() == timeType):
+			return s.WriteString(reflect.Indirect(v).Interface().(time.Time).Format(time.RFC3339))
+		case t.Implements(errorType):
+			return s.WriteString(v.Interface().(error).Error())
+		case t.Implements(stringerType):
+			return s.WriteString(v.Interface().(fmt.Stringer).String())
+		case t == bytesType:
+			return s.WriteString(string(v.Bytes()))
 		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		return o.writePointer(s, v)
+	case reflect.Interface:
+		return o.writeX(s, v.Elem())
+	case reflect.Bool:
+		return s.WriteString(strconv.FormatBool(v.Bool()))
 	case reflect.String:
-		c, err = s.WriteString(x.(string))
-	case reflect.Int:
-		c, err = s.WriteString(strconv.FormatInt(int64(x.(int)), 10))
-	case reflect.Int64:
-		c, err = s.WriteString(strconv.FormatInt(x.(int64), 10))
-	case reflect.Float64:
-		c, err = s.WriteString(strconv.FormatFloat(x.(float64), 'f', -1, 64))
-	case reflect.Slice:
-		v := reflect.ValueOf(x)
-		for i := 0; i < v.Len(); i++ {
-			c, err = s.writeX(v.Index(i).Interface())
-			if err != nil {
-				return size, err
-			}
-			size += c
-			c = 0
-			if s.sliceComma && i < (v.Len()-1) {
-				err = s.WriteByte(s.comma)
-				if err != nil {
-					return size, err
-				}
-				size++
-			}
+		return s.WriteString(v.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return s.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return s.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		prec := -1
+		if o.fixed {
+			prec = o.precision
 		}
+		return s.WriteString(strconv.FormatFloat(v.Float(), 'f', prec, v.Type().Bits()))
+	case reflect.Slice, reflect.Array:
+		return o.writeList(s, v)
 	case reflect.Map:
-		v := reflect.ValueOf(x)
-		m := v.MapRange()
-		mapsize := len(v.MapKeys())
-		i := 0
-		for m.Next() {
-			c, err = s.writeX(m.Key().Interface())
+		return o.writeMap(s, v)
+	case reflect.Struct:
+		return o.writeStruct(s, v)
+	}
+
+	if v.CanInterface() {
+		return s.WriteString(fmt.Sprint(v.Interface()))
+	}
+
+	return 0, nil
+}
+
+// writePointer writes the value pointed to, or the address if it's already being written.
+func (o *options) writePointer(s stringWriter, v reflect.Value) (int, error) {
+	p := v.Pointer()
+	if o.visiting[p] {
+		return s.WriteString("0x" + strconv.FormatUint(uint64(p), 16))
+	}
+
+	if o.visiting == nil {
+		o.visiting = make(map[uintptr]bool)
+	}
+	o.visiting[p] = true
+	defer delete(o.visiting, p)
+	return o.writeX(s, v.Elem())
+}
+
+// writeList writes the elements of a slice or array.
+func (o *options) writeList(s stringWriter, v reflect.Value) (int, error) {
+	var size int
+	for i := 0; i < v.Len(); i++ {
+		c, err := o.writeX(s, v.Index(i))
+		size += c
+		if err != nil {
+			return size, err
+		}
+
+		if o.sliceComma && i < (v.Len()-1) {
+			err = s.WriteByte(o.comma)
 			if err != nil {
 				return size, err
 			}
-			size += c
-			err = s.WriteByte(s.equals)
+			size++
+		}
+	}
+	return size, nil
+}
+
+// writeMap writes key-value pairs sorted by key.
+func (o *options) writeMap(s stringWriter, v reflect.Value) (int, error) {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return lessValue(keys[i], keys[j])
+	})
+
+	var size int
+	for i, k := range keys {
+		c, err := o.writePair(s, k, v.MapIndex(k))
+		size += c
+		if err != nil {
+			return size, err
+		}
+
+		if o.mapComma && i < (len(keys)-1) {
+			err = s.WriteByte(o.comma)
 			if err != nil {
 				return size, err
 			}
 			size++
-			c, err = s.writeX(m.Value().Interface())
+		}
+	}
+	return size, nil
+}
+
+// writeStruct writes exported fields as key-value pairs, in order.
+func (o *options) writeStruct(s stringWriter, v reflect.Value) (int, error) {
+	t := v.Type()
+	var size int
+	first := true
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath != "" {
+			continue
+		}
+
+		if !first && o.mapComma {
+			err := s.WriteByte(o.comma)
 			if err != nil {
 				return size, err
 			}
-			size += c
-			c = 0
-			if s.mapComma && i < (mapsize-1) {
-				err = s.WriteByte(s.comma)
-				if err != nil {
-					return size, err
-				}
-			}
 			size++
-			i++
 		}
-	default:
+		first = false
+		c, err := o.writePair(s, reflect.ValueOf(t.Field(i).Name), v.Field(i))
+		size += c
+		if err != nil {
+			return size, err
+		}
+	}
+	return size, nil
+}
+
+// writePair writes a key, the equals symbol and a value.
+func (o *options) writePair(s stringWriter, k, v reflect.Value) (int, error) {
+	size, err := o.writeX(s, k)
+	if err != nil {
+		return size, err
 	}
-	size += c
-	return size, err
+
+	err = s.WriteByte(o.equals)
+	if err != nil {
+		return size, err
+	}
+	size++
+
+	c, err := o.writeX(s, v)
+	return size + c, err
+}
+
+// lessValue orders map keys: numbers numerically, strings and anything else by text.
+// Interface keys are compared by the values they hold.
+func lessValue(a, b reflect.Value) bool {
+	for a.Kind() == reflect.Interface && !a.IsNil() {
+		a = a.Elem()
+	}
+	for b.Kind() == reflect.Interface && !b.IsNil() {
+		b = b.Elem()
+	}
+
+	if a.Kind() == b.Kind() {
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return a.Int() < b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return a.Uint() < b.Uint()
+		case reflect.Float32, reflect.Float64:
+			return a.Float() < b.Float()
+		case reflect.String:
+			return a.String() < b.String()
+		}
+	}
+
+	return fmt.Sprint(a) < fmt.Sprint(b)
 }
@@ -0,0 +1,117 @@
+package stringer_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Urethramancer/signor/stringer"
+)
+
+type point struct {
+	X, Y int
+	tag  string
+}
+
+type named string
+
+func (n named) String() string {
+	return "name:" + string(n)
+}
+
+func TestWriteI(t *testing.T) {
+	n := 42
+	var nilPtr *int
+	var nilErr error
+	when := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		v      interface{}
+		expect string
+	}{
+		{nil, "<nil>"},
+		{nilErr, "<nil>"},
+		{nilPtr, "<nil>"},
+		{&n, "42"},
+		{uint(7), "7"},
+		{uint64(1 << 63), "9223372036854775808"},
+		{int8(-8), "-8"},
+		{int16(-16), "-16"},
+		{'x', "120"},
+		{int32(1000), "1000"},
+		{string('x'), "x"},
+		{float32(1.5), "1.5"},
+		{2.25, "2.25"},
+		{true, "true"},
+		{[]byte("raw"), "raw"},
+		{errors.New("failed"), "failed"},
+		{named("x"), "name:x"},
+		{when, "2020-01-02T03:04:05Z"},
+		{&when, "2020-01-02T03:04:05Z"},
+		{point{1, 2, "hidden"}, "X=1,Y=2"},
+		{map[string]int{"b": 2, "a": 1, "c": 3}, "a=1,b=2,c=3"},
+		{map[int]string{10: "x", 2: "y"}, "2=y,10=x"},
+		{map[interface{}]int{10: 1, 9: 2, "b": 3, "a": 4}, "9=2,10=1,a=4,b=3"},
+		{[]interface{}{1, nil, "s"}, "1,<nil>,s"},
+	}
+	for _, tc := range tests {
+		s := stringer.New().SetSliceComma(true).SetMapComma(true)
+		size, err := s.WriteI(tc.v)
+		if err != nil {
+			t.Fatalf("Couldn't write %#v: %s", tc.v, err.Error())
+		}
+
+		if s.String() != tc.expect {
+			t.Errorf("Expected %q, got %q", tc.expect, s.String())
+		}
+		if size != s.Len() {
+			t.Errorf("%q: size %d doesn't match length %d", s.String(), size, s.Len())
+		}
+	}
+}
+
+func TestPrecision(t *testing.T) {
+	s := stringer.New().SetPrecision(2)
+	s.WriteI(1.0, " ", float32(2.5))
+	if s.String() != "1.00 2.50" {
+		t.Errorf("Expected two decimals, got %q", s.String())
+	}
+
+	// The zero value keeps the shortest representation.
+	var z stringer.Stringer
+	z.WriteI(0.5)
+	if z.String() != "0.5" {
+		t.Errorf("Expected %q, got %q", "0.5", z.String())
+	}
+}
+
+func TestMapSizeWithoutComma(t *testing.T) {
+	s := stringer.New()
+	size, _ := s.WriteI(map[string]int{"a": 1, "b": 2})
+	if s.String() != "a=1b=2" || size != s.Len() {
+		t.Errorf("Expected %q with size %d, got %q with size %d", "a=1b=2", len("a=1b=2"), s.String(), size)
+	}
+}
+
+type node struct {
+	Name string
+	Next *node
+}
+
+func TestPointerCycle(t *testing.T) {
+	n := &node{Name: "loop"}
+	n.Next = n
+	s := stringer.New().SetMapComma(true)
+	s.WriteI(n)
+	if !strings.HasPrefix(s.String(), "Name=loop,Next=0x") {
+		t.Errorf("Unexpected output %q", s.String())
+	}
+
+	// Shared pointers which don't loop are written in full.
+	leaf := &node{Name: "leaf"}
+	s = stringer.New().SetSliceComma(true)
+	s.WriteI([]*node{leaf, leaf})
+	if s.String() != "Name=leafNext=<nil>,Name=leafNext=<nil>" {
+		t.Errorf("Unexpected output %q", s.String())
+	}
+}
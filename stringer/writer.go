@@ -0,0 +1,122 @@
+package stringer
+
+import (
+	"bufio"
+	"io"
+)
+
+// Writer is a buffered, streaming Stringer. The first error from the underlying
+// writer is kept, and every later write does nothing and returns it, so a series
+// of writes needs only one check at the end with Flush() or Err().
+type Writer struct {
+	options
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+// NewWriter returns a Writer buffering output to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		options: defaultOptions(),
+		w:       bufio.NewWriter(w),
+	}
+}
+
+// SetSliceComma enables adding a comma between elements in supplied slices.
+func (w *Writer) SetSliceComma(b bool) *Writer {
+	w.sliceComma = b
+	return w
+}
+
+// SetMapComma enables adding a comma between elements in supplied maps.
+func (w *Writer) SetMapComma(b bool) *Writer {
+	w.mapComma = b
+	return w
+}
+
+// SetComma sets the symbol to use for joining slices and map k-v pairs.
+func (w *Writer) SetComma(c byte) *Writer {
+	w.comma = c
+	return w
+}
+
+// SetEquals sets the symbol to join keys and values in maps.
+func (w *Writer) SetEquals(e byte) *Writer {
+	w.equals = e
+	return w
+}
+
+// SetPrecision sets the number of decimal places for floating point numbers.
+// A negative precision uses the fewest necessary, which is the default.
+func (w *Writer) SetPrecision(p int) *Writer {
+	w.setPrecision(p)
+	return w
+}
+
+// Write implements io.Writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	w.err = err
+	return n, err
+}
+
+// WriteString writes a string.
+func (w *Writer) WriteString(s string) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	n, err := w.w.WriteString(s)
+	w.n += int64(n)
+	w.err = err
+	return n, err
+}
+
+// WriteByte writes a single byte.
+func (w *Writer) WriteByte(c byte) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	w.err = w.w.WriteByte(c)
+	if w.err == nil {
+		w.n++
+	}
+	return w.err
+}
+
+// WriteStrings writes any number of strings in one go.
+func (w *Writer) WriteStrings(v ...string) (int, error) {
+	return writeStrings(w, v)
+}
+
+// WriteI writes any number of different types at once, like Stringer.WriteI().
+func (w *Writer) WriteI(v ...interface{}) (int, error) {
+	return w.options.writeI(w, v)
+}
+
+// Flush writes any buffered data, returning the first error from any write.
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	w.err = w.w.Flush()
+	return w.err
+}
+
+// Err returns the first error from any write, or nil.
+func (w *Writer) Err() error {
+	return w.err
+}
+
+// Count returns the number of bytes written, including any still buffered.
+func (w *Writer) Count() int64 {
+	return w.n
+}
@@ -0,0 +1,57 @@
+package stringer_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Urethramancer/signor/stringer"
+)
+
+// failWriter accepts limit bytes, then fails.
+type failWriter struct {
+	limit int
+}
+
+var errFull = errors.New("full")
+
+func (f *failWriter) Write(p []byte) (int, error) {
+	if len(p) > f.limit {
+		n := f.limit
+		f.limit = 0
+		return n, errFull
+	}
+
+	f.limit -= len(p)
+	return len(p), nil
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := stringer.NewWriter(&buf).SetSliceComma(true)
+	w.WriteStrings("a", "b")
+	w.WriteI(" ", 1, " ", []string{"x", "y"})
+	err := w.Flush()
+	if err != nil {
+		t.Fatalf("Couldn't flush: %s", err.Error())
+	}
+
+	if buf.String() != "ab 1 x,y" || w.Count() != int64(buf.Len()) {
+		t.Errorf("Expected %q with count %d, got %q with count %d", "ab 1 x,y", buf.Len(), buf.String(), w.Count())
+	}
+}
+
+func TestWriterStickyError(t *testing.T) {
+	w := stringer.NewWriter(&failWriter{limit: 10})
+	for i := 0; i < 5000; i++ {
+		w.WriteStrings("line ", "of text\n")
+	}
+	if !errors.Is(w.Err(), errFull) || !errors.Is(w.Flush(), errFull) {
+		t.Errorf("Expected sticky error, got %v", w.Err())
+	}
+
+	n, err := w.WriteString("more")
+	if n != 0 || !errors.Is(err, errFull) {
+		t.Errorf("Expected no write after error, got %d, %v", n, err)
+	}
+}
@@ -2,6 +2,8 @@ package structure
 
 import (
 	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -39,6 +41,10 @@ type Tags struct {
 		// Omitempty keyword will be output if this is true.
 		Omitempty bool
 	}
+	// Default value for files.SetDefaults().
+	Default string
+	// Validate rules for files.Validate().
+	Validate string
 }
 
 func (f *Field) parseTags(tags string) {
@@ -46,30 +52,22 @@ func (f *Field) parseTags(tags string) {
 		return
 	}
 
-	tags = strings.ReplaceAll(tags, "\"", "")
-	if tags == "" {
-		return
-	}
-
-	j := strings.SplitN(tags, ":", 2)
-	for i := 0; i < len(j); {
-		switch j[i] {
-		case "json":
-			tags := strings.Split(j[1], ",")
-			for i, t := range tags {
-				if i == 0 {
-					f.Tags.JSON.Name = t
-				} else {
-					if t == "omitempty" {
-						f.Tags.JSON.Omitempty = true
-					}
+	st := reflect.StructTag(tags)
+	j, ok := st.Lookup("json")
+	if ok {
+		for i, t := range strings.Split(j, ",") {
+			if i == 0 {
+				f.Tags.JSON.Name = t
+			} else {
+				if t == "omitempty" {
+					f.Tags.JSON.Omitempty = true
 				}
 			}
-			i++
-		default:
-			i++
 		}
 	}
+
+	f.Tags.Default = st.Get("default")
+	f.Tags.Validate = st.Get("validate")
 }
 
 // MakeTags for specified output formats. Currently only JSON is supported.
@@ -116,13 +114,22 @@ func (f *Field) String() string {
 		b.WriteString(f.Value)
 	}
 
+	var tags []string
 	if f.Tags.JSON.Name != "" {
-		b.WriteStrings("\t", "`json:\"", f.Tags.JSON.Name)
+		t := "json:" + strconv.Quote(f.Tags.JSON.Name)
 		if f.Tags.JSON.Omitempty {
-			b.WriteString(",omitempty")
+			t = "json:" + strconv.Quote(f.Tags.JSON.Name+",omitempty")
 		}
-
-		b.WriteString("\"`")
+		tags = append(tags, t)
+	}
+	if f.Tags.Default != "" {
+		tags = append(tags, "default:"+strconv.Quote(f.Tags.Default))
+	}
+	if f.Tags.Validate != "" {
+		tags = append(tags, "validate:"+strconv.Quote(f.Tags.Validate))
+	}
+	if len(tags) > 0 {
+		b.WriteStrings("\t`", strings.Join(tags, " "), "`")
 	}
 
 	return b.String()
@@ -1,6 +1,8 @@
 package structure_test
 
 import (
+	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/Urethramancer/signor/structure"
@@ -17,3 +19,32 @@ func TestFunc(t *testing.T) {
 	t.Logf("Loaded package %s from %s", pkg.Name, pkg.Filename)
 	t.Logf("%s", pkg.Name)
 }
+
+// failWriter fails every write.
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestPackageWriteTo(t *testing.T) {
+	pkg, err := structure.NewPackage("func_test.go")
+	if err != nil {
+		t.Fatalf("Couldn't load myself: %s", err.Error())
+	}
+
+	var b bytes.Buffer
+	n, err := pkg.WriteTo(&b)
+	if err != nil {
+		t.Fatalf("Couldn't write package: %s", err.Error())
+	}
+
+	if b.String() != pkg.String() || n != int64(b.Len()) {
+		t.Errorf("WriteTo() wrote %d bytes, differing from String():\n%s", n, b.String())
+	}
+
+	_, err = pkg.WriteTo(failWriter{})
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("Expected the write error, got %v", err)
+	}
+}
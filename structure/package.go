@@ -1,6 +1,7 @@
 package structure
 
 import (
+	"io"
 	"os"
 	"sort"
 	"strings"
@@ -129,8 +130,17 @@ func (pkg *Package) sortImports() {
 	pkg.ExternalImports = stringer.RemoveDuplicateStrings(pkg.ExternalImports)
 }
 
+// String returns the package source.
 func (pkg *Package) String() string {
-	b := stringer.New()
+	var b strings.Builder
+	pkg.WriteTo(&b)
+	return b.String()
+}
+
+// WriteTo writes the package source to w, returning the number of bytes written
+// and the first error.
+func (pkg *Package) WriteTo(w io.Writer) (int64, error) {
+	b := stringer.NewWriter(w)
 	b.WriteStrings("package ", pkg.Name, "\n\n", "import (\n")
 	if len(pkg.InternalImports) > 0 {
 		for _, inc := range pkg.InternalImports {
@@ -155,7 +165,8 @@ func (pkg *Package) String() string {
 		b.WriteStrings(f.Code, "\n")
 	}
 
-	return b.String()
+	err := b.Flush()
+	return b.Count(), err
 }
 
 // ProtoString generates protocol buffer output.
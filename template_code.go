@@ -1,22 +1,22 @@
 package main
 
 var jsonLoader = `
-// New $STRUCT$ structure.
+// New $STRUCT$ structure with the defaults from its default tags.
 func New() *$STRUCT$ {
 	cfg := &$STRUCT${}
-	// TODO: Fill this with defaults!
+	err := files.SetDefaults(cfg)
+	if err != nil {
+		// Only a default tag which doesn't parse can get here.
+		panic(err)
+	}
+
 	return cfg
 }
 
-// Load a $STRUCT$ structure.
+// Load a $STRUCT$ structure, filling in defaults and checking validate tags.
 func Load(filename string) (*$STRUCT$, error) {
 	var out $STRUCT$
-	data, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return nil, err
-	}
-
-	err = json.Unmarshal(data, &out)
+	err := files.LoadJSON(filename, &out)
 	if err != nil {
 		return nil, err
 	}
@@ -26,13 +26,6 @@ func Load(filename string) (*$STRUCT$, error) {
 
 // Save the $STRUCT$ structure.
 func (c *$STRUCT$) Save(filename string) error {
-	var out []byte
-	out, err := json.MarshalIndent(c, "\t", "")
-	if err != nil {
-		return err
-	}
-
-	ioutil.WriteFile(filename, out, 0600)
-	return nil
+	return files.SaveJSON(filename, c)
 }
 `